@@ -6,13 +6,27 @@ import (
 )
 
 type gscConfig struct {
-	Credentials *string `cty:"credentials"`
+	Credentials               *string  `cty:"credentials"`
+	SearchAnalyticsSplitByDay *bool    `cty:"search_analytics_split_by_day"`
+	BrandTerms                []string `cty:"brand_terms"`
+	PageGroups                []string `cty:"page_groups"`
 }
 
 var ConfigSchema = map[string]*schema.Attribute{
 	"credentials": {
 		Type: schema.TypeString,
 	},
+	"search_analytics_split_by_day": {
+		Type: schema.TypeBool,
+	},
+	"brand_terms": {
+		Type: schema.TypeList,
+		Elem: &schema.Attribute{Type: schema.TypeString},
+	},
+	"page_groups": {
+		Type: schema.TypeList,
+		Elem: &schema.Attribute{Type: schema.TypeString},
+	},
 }
 
 func ConfigInstance() interface{} {
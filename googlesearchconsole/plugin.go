@@ -22,11 +22,29 @@ func Plugin(ctx context.Context) *plugin.Plugin {
 			Schema:      ConfigSchema,
 		},
 		TableMap: map[string]*plugin.Table{
-			"googlesearchconsole_indexing_status":               tableGoogleSearchConsoleIndexingStatus(ctx),
-			"googlesearchconsole_pagespeed_analysis":            tableGoogleSearchConsolePagespeedAnalysis(ctx),
-			"googlesearchconsole_pagespeed_analysis_aggregated": tableGoogleSearchConsolePagespeedAnalysisAggregated(ctx),
-			"googlesearchconsole_site":                          tableGoogleSearchConsoleSite(ctx),
-			"googlesearchconsole_sitemap":                       tableGoogleSearchConsoleSitemap(ctx),
+			"googlesearchconsole_ctr_curve":                             tableGoogleSearchConsoleCtrCurve(ctx),
+			"googlesearchconsole_ctr_gap":                               tableGoogleSearchConsoleCtrGap(ctx),
+			"googlesearchconsole_data_freshness":                        tableGoogleSearchConsoleDataFreshness(ctx),
+			"googlesearchconsole_indexing_status":                       tableGoogleSearchConsoleIndexingStatus(ctx),
+			"googlesearchconsole_keyword_cannibalization":               tableGoogleSearchConsoleKeywordCannibalization(ctx),
+			"googlesearchconsole_pagespeed_analysis":                    tableGoogleSearchConsolePagespeedAnalysis(ctx),
+			"googlesearchconsole_pagespeed_analysis_aggregated":         tableGoogleSearchConsolePagespeedAnalysisAggregated(ctx),
+			"googlesearchconsole_query_ngram":                           tableGoogleSearchConsoleQueryNgram(ctx),
+			"googlesearchconsole_rich_result_item":                      tableGoogleSearchConsoleRichResultItem(ctx),
+			"googlesearchconsole_search_analytics":                      tableGoogleSearchConsoleSearchAnalytics(ctx),
+			"googlesearchconsole_search_analytics_by_country":           tableGoogleSearchConsoleSearchAnalyticsByCountry(ctx),
+			"googlesearchconsole_search_analytics_by_date":              tableGoogleSearchConsoleSearchAnalyticsByDate(ctx),
+			"googlesearchconsole_search_analytics_by_device":            tableGoogleSearchConsoleSearchAnalyticsByDevice(ctx),
+			"googlesearchconsole_search_analytics_by_page":              tableGoogleSearchConsoleSearchAnalyticsByPage(ctx),
+			"googlesearchconsole_search_analytics_by_query":             tableGoogleSearchConsoleSearchAnalyticsByQuery(ctx),
+			"googlesearchconsole_search_analytics_by_search_appearance": tableGoogleSearchConsoleSearchAnalyticsBySearchAppearance(ctx),
+			"googlesearchconsole_search_analytics_comparison":           tableGoogleSearchConsoleSearchAnalyticsComparison(ctx),
+			"googlesearchconsole_search_analytics_hourly":               tableGoogleSearchConsoleSearchAnalyticsHourly(ctx),
+			"googlesearchconsole_site":                                  tableGoogleSearchConsoleSite(ctx),
+			"googlesearchconsole_sitemap":                               tableGoogleSearchConsoleSitemap(ctx),
+			"googlesearchconsole_sitemap_reconciliation":                tableGoogleSearchConsoleSitemapReconciliation(ctx),
+			"googlesearchconsole_striking_distance":                     tableGoogleSearchConsoleStrikingDistance(ctx),
+			"googlesearchconsole_traffic_anomaly":                       tableGoogleSearchConsoleTrafficAnomaly(ctx),
 		},
 	}
 	return p
@@ -120,8 +120,9 @@ func getPagespeedTokenSource(ctx context.Context, d *plugin.QueryData) (oauth2.T
 	return ts, nil
 }
 
-// getPageIndexingStatusService returns the indexing status of a page
-func getPageIndexingStatusService(ctx context.Context, d *plugin.QueryData, pageURL string, siteUrl string) (*searchconsole.UrlInspectionResult, error) {
+// getPageIndexingStatusService returns the indexing status of a page, with the issue messages
+// translated to the given language if set
+func getPageIndexingStatusService(ctx context.Context, d *plugin.QueryData, pageURL string, siteUrl string, languageCode string) (*searchconsole.UrlInspectionResult, error) {
 	// Create client
 	opts, err := getSearchConsoleSessionConfig(ctx, d)
 	if err != nil {
@@ -139,6 +140,7 @@ func getPageIndexingStatusService(ctx context.Context, d *plugin.QueryData, page
 	req := searchconsole.InspectUrlIndexRequest{
 		InspectionUrl: pageURL,
 		SiteUrl:       siteUrl,
+		LanguageCode:  languageCode,
 	}
 	resp, err := svc.UrlInspection.Index.Inspect(&req).Context(ctx).Do()
 	if err != nil {
@@ -171,3 +173,75 @@ func getPagespeedAnalysisService(ctx context.Context, d *plugin.QueryData, pageU
 	}
 	return resp, nil
 }
+
+// getSearchAnalyticsService returns the search analytics data of a site
+func getSearchAnalyticsService(ctx context.Context, d *plugin.QueryData, siteUrl string, req *searchconsole.SearchAnalyticsQueryRequest) (*searchconsole.SearchAnalyticsQueryResponse, error) {
+	// Create client
+	opts, err := getSearchConsoleSessionConfig(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("getSearchAnalyticsService", "connection_error", err)
+		return nil, err
+	}
+
+	// Create service
+	svc, err := searchconsole.NewService(ctx, opts...)
+	if err != nil {
+		plugin.Logger(ctx).Error("getSearchAnalyticsService", "service_creation_error", err)
+		return nil, err
+	}
+
+	resp, err := svc.Searchanalytics.Query(siteUrl, req).Context(ctx).Do()
+	if err != nil {
+		plugin.Logger(ctx).Error("getSearchAnalyticsService", "api_error", err)
+		return nil, err
+	}
+	return resp, nil
+}
+
+// getSitesService returns the sites of the user
+func getSitesService(ctx context.Context, d *plugin.QueryData) ([]*searchconsole.WmxSite, error) {
+	// Create client
+	opts, err := getSearchConsoleSessionConfig(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("getSitesService", "connection_error", err)
+		return nil, err
+	}
+
+	// Create service
+	svc, err := searchconsole.NewService(ctx, opts...)
+	if err != nil {
+		plugin.Logger(ctx).Error("getSitesService", "service_creation_error", err)
+		return nil, err
+	}
+
+	resp, err := svc.Sites.List().Context(ctx).Do()
+	if err != nil {
+		plugin.Logger(ctx).Error("getSitesService", "api_error", err)
+		return nil, err
+	}
+	return resp.SiteEntry, nil
+}
+
+// getSitemapsService returns the sitemaps submitted for a site
+func getSitemapsService(ctx context.Context, d *plugin.QueryData, siteUrl string) ([]*searchconsole.WmxSitemap, error) {
+	// Create client
+	opts, err := getSearchConsoleSessionConfig(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("getSitemapsService", "connection_error", err)
+		return nil, err
+	}
+
+	// Create service
+	svc, err := searchconsole.NewService(ctx, opts...)
+	if err != nil {
+		plugin.Logger(ctx).Error("getSitemapsService", "service_creation_error", err)
+		return nil, err
+	}
+
+	resp, err := svc.Sitemaps.List(siteUrl).Context(ctx).Do()
+	if err != nil {
+		plugin.Logger(ctx).Error("getSitemapsService", "api_error", err)
+		return nil, err
+	}
+	return resp.Sitemap, nil
+}
@@ -0,0 +1,172 @@
+package googlesearchconsole
+
+import (
+	"context"
+	"math"
+	"slices"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"google.golang.org/api/searchconsole/v1"
+)
+
+//// TABLE DEFINITION
+
+func tableGoogleSearchConsoleCtrCurve(_ context.Context) *plugin.Table {
+	return &plugin.Table{
+		Name:        "googlesearchconsole_ctr_curve",
+		Description: "Lists the click-through rate of a site by average position, rounded to the nearest integer.",
+		List: &plugin.ListConfig{
+			KeyColumns: getSearchAnalyticsKeyColumns(nil),
+			Hydrate:    listCtrCurve,
+		},
+		Columns: getCtrCurveColumns(),
+	}
+}
+
+// getCtrCurveColumns returns the search analytics columns, with the position column holding
+// the rounded position of the curve.
+func getCtrCurveColumns() []*plugin.Column {
+	var columns []*plugin.Column
+	for _, column := range getSearchAnalyticsColumns(nil) {
+		switch column.Name {
+		case "position":
+			column.Description = "The average position of the query and page pairs, rounded to the nearest integer."
+			column.Type = proto.ColumnType_INT
+			columns = append(columns, column, &plugin.Column{
+				Name:        "query_page_pairs",
+				Description: "The number of query and page pairs with impressions at the position.",
+				Type:        proto.ColumnType_INT,
+			})
+			continue
+		case "clicks":
+			column.Description = "The number of clicks of the query and page pairs at the position."
+		case "impressions":
+			column.Description = "The number of impressions of the query and page pairs at the position."
+		case "ctr":
+			column.Description = "The click-through rate at the position, between 0 and 1, weighted by impressions."
+		}
+		columns = append(columns, column)
+	}
+	return columns
+}
+
+type CtrCurveRow struct {
+	SearchAnalyticsRow
+	QueryPagePairs int
+}
+
+//// LIST FUNCTION
+
+func listCtrCurve(ctx context.Context, d *plugin.QueryData, _ *plugin.HydrateData) (interface{}, error) {
+	if d.EqualsQualString("site_url") == "" {
+		return nil, nil
+	}
+
+	req, template, err := buildSearchAnalyticsRequest(d, []string{"query", "page"}, "final", 28)
+	if err != nil {
+		plugin.Logger(ctx).Error("googlesearchconsole_ctr_curve.listCtrCurve", "validation_error", err)
+		return nil, err
+	}
+
+	rows, err := getAllSearchAnalyticsRows(ctx, d, req, template)
+	if err != nil {
+		plugin.Logger(ctx).Error("googlesearchconsole_ctr_curve.listCtrCurve", "api_error", err)
+		return nil, err
+	}
+
+	curve := newSearchAnalyticsCtrCurve(rows)
+	for _, bucket := range curve.Buckets() {
+		row := &CtrCurveRow{
+			SearchAnalyticsRow: *template,
+			QueryPagePairs:     bucket.Rows,
+		}
+		row.Position = float64(bucket.Position)
+		row.Clicks = bucket.Clicks
+		row.Impressions = bucket.Impressions
+		row.Ctr = bucket.Clicks / bucket.Impressions
+		if len(rows) > 0 {
+			row.ResponseAggregationType = rows[0].ResponseAggregationType
+		}
+		d.StreamListItem(ctx, row)
+
+		// Context can be cancelled due to manual cancellation or the limit has been hit
+		if d.RowsRemaining(ctx) == 0 {
+			return nil, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// searchAnalyticsCtrBucket holds the totals of the rows whose average position rounds to Position.
+type searchAnalyticsCtrBucket struct {
+	Position    int
+	Rows        int
+	Clicks      float64
+	Impressions float64
+}
+
+// searchAnalyticsCtrCurve is the click-through rate of a site by rounded average position.
+type searchAnalyticsCtrCurve map[int]*searchAnalyticsCtrBucket
+
+// newSearchAnalyticsCtrCurve computes the CTR curve of the given rows, weighting each row
+// by its impressions.
+func newSearchAnalyticsCtrCurve(rows []*SearchAnalyticsRow) searchAnalyticsCtrCurve {
+	curve := searchAnalyticsCtrCurve{}
+	for _, row := range rows {
+		if row.Impressions == 0 {
+			continue
+		}
+		position := int(math.Round(row.Position))
+		bucket, ok := curve[position]
+		if !ok {
+			bucket = &searchAnalyticsCtrBucket{Position: position}
+			curve[position] = bucket
+		}
+		bucket.Rows++
+		bucket.Clicks += row.Clicks
+		bucket.Impressions += row.Impressions
+	}
+	return curve
+}
+
+// getSiteSearchAnalyticsCtrCurve computes the CTR curve of all the query and page pairs of the
+// site, without the dimension filters of the request, so that it does not depend on the quals.
+// The rows of the request are reused when it has no dimension filters.
+func getSiteSearchAnalyticsCtrCurve(ctx context.Context, d *plugin.QueryData, req *searchconsole.SearchAnalyticsQueryRequest, template *SearchAnalyticsRow, rows []*SearchAnalyticsRow) (searchAnalyticsCtrCurve, error) {
+	if len(req.DimensionFilterGroups) == 0 {
+		return newSearchAnalyticsCtrCurve(rows), nil
+	}
+
+	siteReq := *req
+	siteReq.DimensionFilterGroups = nil
+	siteRows, err := getAllSearchAnalyticsRows(ctx, d, &siteReq, template)
+	if err != nil {
+		return nil, err
+	}
+	return newSearchAnalyticsCtrCurve(siteRows), nil
+}
+
+// Ctr returns the click-through rate at the given position, or nil if there were no
+// impressions at that position.
+func (c searchAnalyticsCtrCurve) Ctr(position int) *float64 {
+	bucket, ok := c[position]
+	if !ok || bucket.Impressions == 0 {
+		return nil
+	}
+	ctr := bucket.Clicks / bucket.Impressions
+	return &ctr
+}
+
+// Buckets returns the buckets of the curve, ordered by position.
+func (c searchAnalyticsCtrCurve) Buckets() []*searchAnalyticsCtrBucket {
+	var buckets []*searchAnalyticsCtrBucket
+	for _, bucket := range c {
+		buckets = append(buckets, bucket)
+	}
+	slices.SortFunc(buckets, func(a, b *searchAnalyticsCtrBucket) int {
+		return a.Position - b.Position
+	})
+	return buckets
+}
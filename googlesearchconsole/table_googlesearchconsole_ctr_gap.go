@@ -0,0 +1,95 @@
+package googlesearchconsole
+
+import (
+	"context"
+	"math"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+//// TABLE DEFINITION
+
+func tableGoogleSearchConsoleCtrGap(_ context.Context) *plugin.Table {
+	dimensions := []searchAnalyticsDimension{searchAnalyticsQueryDimension, searchAnalyticsPageDimension}
+	return &plugin.Table{
+		Name:        "googlesearchconsole_ctr_gap",
+		Description: "Lists the query and page pairs of a site with their click-through rate compared to the click-through rate expected at their position.",
+		List: &plugin.ListConfig{
+			KeyColumns: getSearchAnalyticsKeyColumns(dimensions),
+			Hydrate:    listCtrGaps,
+		},
+		Columns: append(getSearchAnalyticsColumns(dimensions), []*plugin.Column{
+			{
+				Name:        "expected_ctr",
+				Description: "The click-through rate of the site at the rounded position of the query and page pair, between 0 and 1.",
+				Type:        proto.ColumnType_DOUBLE,
+				Transform:   transform.FromField("ExpectedCtr"),
+			},
+			{
+				Name:        "actual_ctr",
+				Description: "The click-through rate of the query and page pair, between 0 and 1.",
+				Type:        proto.ColumnType_DOUBLE,
+				Transform:   transform.FromField("Ctr"),
+			},
+			{
+				Name:        "ctr_gap",
+				Description: "The difference between the actual and the expected click-through rate. A negative value means the pair gets fewer clicks than expected at its position.",
+				Type:        proto.ColumnType_DOUBLE,
+				Transform:   transform.FromField("CtrGap"),
+			},
+		}...),
+	}
+}
+
+type CtrGapRow struct {
+	SearchAnalyticsRow
+	ExpectedCtr *float64
+	CtrGap      *float64
+}
+
+//// LIST FUNCTION
+
+func listCtrGaps(ctx context.Context, d *plugin.QueryData, _ *plugin.HydrateData) (interface{}, error) {
+	if d.EqualsQualString("site_url") == "" {
+		return nil, nil
+	}
+
+	req, template, err := buildSearchAnalyticsRequest(d, []string{"query", "page"}, "final", 28)
+	if err != nil {
+		plugin.Logger(ctx).Error("googlesearchconsole_ctr_gap.listCtrGaps", "validation_error", err)
+		return nil, err
+	}
+
+	// The CTR curve is computed from all the rows, so they are fetched before streaming
+	rows, err := getAllSearchAnalyticsRows(ctx, d, req, template)
+	if err != nil {
+		plugin.Logger(ctx).Error("googlesearchconsole_ctr_gap.listCtrGaps", "api_error", err)
+		return nil, err
+	}
+
+	curve, err := getSiteSearchAnalyticsCtrCurve(ctx, d, req, template, rows)
+	if err != nil {
+		plugin.Logger(ctx).Error("googlesearchconsole_ctr_gap.listCtrGaps", "api_error", err)
+		return nil, err
+	}
+	for _, row := range rows {
+		result := &CtrGapRow{
+			SearchAnalyticsRow: *row,
+			ExpectedCtr:        curve.Ctr(int(math.Round(row.Position))),
+		}
+		if result.ExpectedCtr != nil {
+			ctrGap := row.Ctr - *result.ExpectedCtr
+			result.CtrGap = &ctrGap
+		}
+		d.StreamListItem(ctx, result)
+
+		// Context can be cancelled due to manual cancellation or the limit has been hit
+		if d.RowsRemaining(ctx) == 0 {
+			return nil, nil
+		}
+	}
+
+	return nil, nil
+}
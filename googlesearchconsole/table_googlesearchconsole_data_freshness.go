@@ -0,0 +1,182 @@
+package googlesearchconsole
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+	"google.golang.org/api/searchconsole/v1"
+)
+
+const (
+	dataFreshnessLookbackDays  = 30
+	dataFreshnessMaxConcurrent = 5
+)
+
+//// TABLE DEFINITION
+
+func tableGoogleSearchConsoleDataFreshness(_ context.Context) *plugin.Table {
+	return &plugin.Table{
+		Name:        "googlesearchconsole_data_freshness",
+		Description: "Lists the latest dates with final and fresh search analytics data, per site and search type.",
+		List: &plugin.ListConfig{
+			KeyColumns: []*plugin.KeyColumn{
+				{
+					Name:    "site_url",
+					Require: plugin.Optional,
+				},
+				{
+					Name:    "search_type",
+					Require: plugin.Optional,
+				},
+			},
+			Hydrate: listDataFreshness,
+		},
+		Columns: []*plugin.Column{
+			{
+				Name:        "site_url",
+				Description: "The URL of the site.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "search_type",
+				Description: "The search type (web, image, video, news, discover or googleNews).",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "latest_final_date",
+				Description: "The latest date with final data, in PT (UTC - 8:00). Null if there was no data in the last 30 days.",
+				Type:        proto.ColumnType_TIMESTAMP,
+			},
+			{
+				Name:        "latest_fresh_date",
+				Description: "The latest date with data, including fresh data that may still change, in PT (UTC - 8:00). Null if there was no data in the last 30 days.",
+				Type:        proto.ColumnType_TIMESTAMP,
+			},
+			{
+				Name:        "project",
+				Description: "The GCP Project associated with the credentials in use.",
+				Type:        proto.ColumnType_STRING,
+				Hydrate:     getProject,
+				Transform:   transform.FromValue(),
+			},
+		},
+	}
+}
+
+type DataFreshnessRow struct {
+	SiteUrl         string
+	SearchType      string
+	LatestFinalDate *time.Time
+	LatestFreshDate *time.Time
+	err             error
+}
+
+//// LIST FUNCTION
+
+func listDataFreshness(ctx context.Context, d *plugin.QueryData, _ *plugin.HydrateData) (interface{}, error) {
+	// The site_url and search_type quals can both be lists, each combination is fetched
+	siteUrls := getQualStringValues(d, "site_url")
+	if len(siteUrls) == 0 {
+		sites, err := getSitesService(ctx, d)
+		if err != nil {
+			plugin.Logger(ctx).Error("googlesearchconsole_data_freshness.listDataFreshness", "api_error", err)
+			return nil, err
+		}
+		for _, site := range sites {
+			// Search analytics data is not available for unverified sites
+			if site.PermissionLevel != "siteUnverifiedUser" {
+				siteUrls = append(siteUrls, site.SiteUrl)
+			}
+		}
+	}
+
+	searchTypes := searchAnalyticsSearchTypes
+	if values := getQualStringValues(d, "search_type"); len(values) > 0 {
+		for _, searchType := range values {
+			if !slices.Contains(searchAnalyticsSearchTypes, searchType) {
+				err := fmt.Errorf("invalid search_type %q, the search_type should be one of 'web', 'image', 'video', 'news', 'discover' or 'googleNews'", searchType)
+				plugin.Logger(ctx).Error("googlesearchconsole_data_freshness.listDataFreshness", "validation_error", err)
+				return nil, err
+			}
+		}
+		searchTypes = values
+	}
+
+	var rows []*DataFreshnessRow
+	for _, siteUrl := range siteUrls {
+		for _, searchType := range searchTypes {
+			rows = append(rows, &DataFreshnessRow{SiteUrl: siteUrl, SearchType: searchType})
+		}
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, dataFreshnessMaxConcurrent)
+	for _, row := range rows {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(row *DataFreshnessRow) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			row.LatestFinalDate, row.err = getSearchAnalyticsLatestDate(ctx, d, row.SiteUrl, row.SearchType, "final")
+			if row.err == nil {
+				row.LatestFreshDate, row.err = getSearchAnalyticsLatestDate(ctx, d, row.SiteUrl, row.SearchType, "all")
+			}
+		}(row)
+	}
+	wg.Wait()
+
+	for _, row := range rows {
+		if row.err != nil {
+			plugin.Logger(ctx).Error("googlesearchconsole_data_freshness.listDataFreshness", "api_error", row.err)
+			return nil, row.err
+		}
+		d.StreamListItem(ctx, row)
+
+		// Context can be cancelled due to manual cancellation or the limit has been hit
+		if d.RowsRemaining(ctx) == 0 {
+			return nil, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// getSearchAnalyticsLatestDate returns the latest date with data of the site in the last
+// dataFreshnessLookbackDays days, or nil if there is none.
+func getSearchAnalyticsLatestDate(ctx context.Context, d *plugin.QueryData, siteUrl string, searchType string, dataState string) (*time.Time, error) {
+	endDate := time.Now().UTC().Truncate(24 * time.Hour)
+	req := &searchconsole.SearchAnalyticsQueryRequest{
+		StartDate:  endDate.AddDate(0, 0, -dataFreshnessLookbackDays).Format(searchAnalyticsDateFormat),
+		EndDate:    endDate.Format(searchAnalyticsDateFormat),
+		Type:       searchType,
+		DataState:  dataState,
+		Dimensions: []string{"date"},
+		RowLimit:   dataFreshnessLookbackDays + 1,
+	}
+
+	resp, err := getSearchAnalyticsService(ctx, d, siteUrl, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var latest *time.Time
+	for _, apiRow := range resp.Rows {
+		if len(apiRow.Keys) == 0 {
+			continue
+		}
+		date, err := time.Parse(searchAnalyticsDateFormat, apiRow.Keys[0])
+		if err != nil {
+			continue
+		}
+		if latest == nil || date.After(*latest) {
+			latest = &date
+		}
+	}
+	return latest, nil
+}
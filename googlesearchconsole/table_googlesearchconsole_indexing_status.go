@@ -1,28 +1,49 @@
 package googlesearchconsole
 
 import (
+	"cmp"
 	"context"
-	"sync"
+	"fmt"
+	"slices"
+	"time"
 
 	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/memoize"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
 	sitemapper "github.com/yterajima/go-sitemap"
 	"google.golang.org/api/searchconsole/v1"
 )
 
+const (
+	indexingStatusUrlSourceSitemap               = "sitemap"
+	indexingStatusUrlSourceLoc                   = "loc"
+	indexingStatusUrlSourceTopPagesByClicks      = "top_pages_by_clicks"
+	indexingStatusUrlSourceTopPagesByImpressions = "top_pages_by_impressions"
+
+	indexingStatusDefaultTopPagesLimit = 100
+)
+
+var indexingStatusUrlSources = []string{
+	indexingStatusUrlSourceSitemap,
+	indexingStatusUrlSourceLoc,
+	indexingStatusUrlSourceTopPagesByClicks,
+	indexingStatusUrlSourceTopPagesByImpressions,
+}
+
 //// TABLE DEFINITION
 
 func tableGoogleSearchConsoleIndexingStatus(_ context.Context) *plugin.Table {
 	return &plugin.Table{
 		Name:        "googlesearchconsole_indexing_status",
-		Description: "Lists the indexing status of the URLs in the sitemap.",
+		Description: "Lists the indexing status of the URLs in the sitemap, of the given URLs, or of the top pages of the search analytics data.",
 		List: &plugin.ListConfig{
-			KeyColumns: plugin.AllColumns([]string{"site_url", "sitemap_url"}),
+			KeyColumns: append(plugin.SingleColumn("site_url"), getIndexingStatusOptionalKeyColumns()...),
 			Hydrate:    listIndexingStatuses,
 		},
+		// Lists of pages, with "in" or a join, are inspected with a get call per page
 		Get: &plugin.GetConfig{
-			KeyColumns: plugin.AllColumns([]string{"site_url", "loc"}),
+			KeyColumns: append(plugin.AllColumns([]string{"site_url", "loc"}), getIndexingStatusOptionalKeyColumns()...),
 			Hydrate:    getIndexingStatus,
 		},
 		Columns: []*plugin.Column{
@@ -31,6 +52,11 @@ func tableGoogleSearchConsoleIndexingStatus(_ context.Context) *plugin.Table {
 				Description: "The URL of the page.",
 				Type:        proto.ColumnType_STRING,
 			},
+			{
+				Name:        "page_group",
+				Description: "The name of the first page group of the connection config whose regular expression matches the URL of the page.",
+				Type:        proto.ColumnType_STRING,
+			},
 			{
 				Name:        "site_url",
 				Description: "The URL of the site.",
@@ -43,6 +69,37 @@ func tableGoogleSearchConsoleIndexingStatus(_ context.Context) *plugin.Table {
 				Type:        proto.ColumnType_STRING,
 				Transform:   transform.FromQual("sitemap_url"),
 			},
+			{
+				Name:        "language_code",
+				Description: "The language of the translated issue messages, as an IETF BCP-47 language code (e.g. en-US or de-CH). Default is English.",
+				Type:        proto.ColumnType_STRING,
+				Transform:   transform.FromQual("language_code"),
+			},
+			{
+				Name:        "url_source",
+				Description: "Where the URL of the page comes from: sitemap, loc, top_pages_by_clicks or top_pages_by_impressions. Defaults to sitemap if sitemap_url is set, otherwise loc.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "top_pages_limit",
+				Description: "The number of top pages of the search analytics data to inspect, when url_source is top_pages_by_clicks or top_pages_by_impressions. Default is 100.",
+				Type:        proto.ColumnType_INT,
+			},
+			{
+				Name:        "start_date",
+				Description: "Start date of the search analytics date range the top pages are taken from, in PT (UTC - 8:00). Defaults to 28 days before end_date.",
+				Type:        proto.ColumnType_TIMESTAMP,
+			},
+			{
+				Name:        "end_date",
+				Description: "End date of the search analytics date range the top pages are taken from, in PT (UTC - 8:00). Defaults to the current date.",
+				Type:        proto.ColumnType_TIMESTAMP,
+			},
+			{
+				Name:        "inspection_error",
+				Description: "The error returned when inspecting the page, if it could not be inspected. The inspection result columns are then null.",
+				Type:        proto.ColumnType_STRING,
+			},
 			{
 				Name:        "coverage_state",
 				Description: "Could Google find and index the page.",
@@ -109,6 +166,48 @@ func tableGoogleSearchConsoleIndexingStatus(_ context.Context) *plugin.Table {
 				Type:        proto.ColumnType_JSON,
 				Transform:   transform.FromField("UrlInspectionResult.IndexStatusResult.ReferringUrls"),
 			},
+			{
+				Name:        "sitemap",
+				Description: "Any sitemaps listing this URL, as known to Google.",
+				Type:        proto.ColumnType_JSON,
+				Transform:   transform.FromField("UrlInspectionResult.IndexStatusResult.Sitemap"),
+			},
+			{
+				Name:        "mobile_usability_verdict",
+				Description: "High level mobile-usability inspection verdict for the URL.",
+				Type:        proto.ColumnType_STRING,
+				Transform:   transform.FromField("UrlInspectionResult.MobileUsabilityResult.Verdict"),
+			},
+			{
+				Name:        "mobile_usability_issues",
+				Description: "A list of zero or more mobile-usability issues detected for this URL.",
+				Type:        proto.ColumnType_JSON,
+				Transform:   transform.FromField("UrlInspectionResult.MobileUsabilityResult.Issues"),
+			},
+			{
+				Name:        "rich_results_verdict",
+				Description: "High level rich results inspection verdict for the URL, based on the most severe issue of its detected items.",
+				Type:        proto.ColumnType_STRING,
+				Transform:   transform.FromField("UrlInspectionResult.RichResultsResult.Verdict"),
+			},
+			{
+				Name:        "rich_results_items",
+				Description: "The rich result types detected for this URL, with their items and issues.",
+				Type:        proto.ColumnType_JSON,
+				Transform:   transform.FromField("UrlInspectionResult.RichResultsResult.DetectedItems"),
+			},
+			{
+				Name:        "amp_verdict",
+				Description: "High level AMP inspection verdict for the URL. Null if the URL has no AMP version.",
+				Type:        proto.ColumnType_STRING,
+				Transform:   transform.FromField("UrlInspectionResult.AmpResult.Verdict"),
+			},
+			{
+				Name:        "amp_issues",
+				Description: "A list of zero or more AMP issues found for the inspected URL.",
+				Type:        proto.ColumnType_JSON,
+				Transform:   transform.FromField("UrlInspectionResult.AmpResult.Issues"),
+			},
 			{
 				Name:        "project",
 				Description: "The GCP Project associated with the credentials in use.",
@@ -120,54 +219,86 @@ func tableGoogleSearchConsoleIndexingStatus(_ context.Context) *plugin.Table {
 	}
 }
 
+// getIndexingStatusOptionalKeyColumns returns the optional key columns of both the list and
+// get calls, which select where the URLs come from and how they are inspected.
+func getIndexingStatusOptionalKeyColumns() []*plugin.KeyColumn {
+	return []*plugin.KeyColumn{
+		{
+			Name:    "sitemap_url",
+			Require: plugin.Optional,
+		},
+		{
+			Name:       "language_code",
+			Require:    plugin.Optional,
+			CacheMatch: "exact",
+		},
+		{
+			Name:       "url_source",
+			Require:    plugin.Optional,
+			CacheMatch: "exact",
+		},
+		{
+			Name:       "top_pages_limit",
+			Require:    plugin.Optional,
+			CacheMatch: "exact",
+		},
+		{
+			Name:       "start_date",
+			Require:    plugin.Optional,
+			CacheMatch: "exact",
+		},
+		{
+			Name:       "end_date",
+			Require:    plugin.Optional,
+			CacheMatch: "exact",
+		},
+	}
+}
+
 type StatusPerURL struct {
 	Loc                 string
 	ChangeFreq          string
 	LastMod             string
 	Priority            float32
+	PageGroup           string
+	UrlSource           string
+	TopPagesLimit       *int64
+	StartDate           *time.Time
+	EndDate             *time.Time
+	InspectionError     string
 	UrlInspectionResult *searchconsole.UrlInspectionResult
+	languageCode        string
 }
 
 //// LIST FUNCTION
 
 func listIndexingStatuses(ctx context.Context, d *plugin.QueryData, _ *plugin.HydrateData) (interface{}, error) {
 	siteUrl := d.EqualsQualString("site_url")
-	smUrl := d.EqualsQualString("sitemap_url")
 
-	if smUrl == "" {
-		plugin.Logger(ctx).Error("sitemap_url is required")
+	urls, template, err := getIndexingStatusURLs(ctx, d, nil)
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		plugin.Logger(ctx).Error("googlesearchconsole_indexing_status.listIndexingStatuses", "validation_error", "The sitemap_url, loc or url_source must be specified.")
 		return nil, nil
 	}
 
-	sitemapURLs, err := sitemapper.Get(smUrl, nil)
+	pageGroups, err := getPageGroups(d)
 	if err != nil {
-		plugin.Logger(ctx).Error("googlesearchconsole_indexing_status.listIndexingStatuses", "sitemap_error", err)
+		plugin.Logger(ctx).Error("googlesearchconsole_indexing_status.listIndexingStatuses", "validation_error", err)
 		return nil, err
 	}
 
-	batches := createBatches(sitemapURLs.URL, 50) // Assuming a batchSize of 50
-
-	var wg sync.WaitGroup
-	wg.Add(len(batches))
+	// Pages that could not be inspected are still returned, with their inspection_error set
+	statuses := getPageIndexingStatuses(ctx, d, siteUrl, template.languageCode, urls)
+	for _, url := range urls {
+		d.StreamListItem(ctx, newIndexingStatus(template, url, pageGroups, statuses[url.Loc]))
 
-	for i, batch := range batches {
-		go processPageIndexingStatusBatch(ctx, d, siteUrl, batch, i, &wg)
-	}
-	wg.Wait() // Wait for all batches to complete
-
-	for _, sitemapURL := range sitemapURLs.URL {
-		status := StatusPerURL{
-			Loc:                 sitemapURL.Loc,
-			ChangeFreq:          sitemapURL.ChangeFreq,
-			LastMod:             sitemapURL.LastMod,
-			Priority:            sitemapURL.Priority,
-			UrlInspectionResult: statusPerUrl[sitemapURL.Loc],
+		// Context can be cancelled due to manual cancellation or the limit has been hit
+		if d.RowsRemaining(ctx) == 0 {
+			return nil, nil
 		}
-		d.StreamListItem(ctx, status)
-	}
-
-	for k := range statusPerUrl {
-		delete(statusPerUrl, k)
 	}
 
 	return nil, nil
@@ -183,16 +314,240 @@ func getIndexingStatus(ctx context.Context, d *plugin.QueryData, h *plugin.Hydra
 		return nil, nil
 	}
 
-	resp, err := getPageIndexingStatusService(ctx, d, pageUrl, siteUrl)
+	// The page is only returned if it is part of the url source, e.g. the given sitemap
+	urls, template, err := getIndexingStatusURLs(ctx, d, []string{pageUrl})
+	if err != nil {
+		return nil, err
+	}
+	if len(urls) == 0 {
+		return nil, nil
+	}
+
+	pageGroups, err := getPageGroups(d)
+	if err != nil {
+		plugin.Logger(ctx).Error("googlesearchconsole_indexing_status.getIndexingStatus", "validation_error", err)
+		return nil, err
+	}
+
+	resp, err := getPageIndexingStatusService(ctx, d, pageUrl, siteUrl, template.languageCode)
+	if err != nil {
+		plugin.Logger(ctx).Warn("googlesearchconsole_indexing_status.getIndexingStatus", "api_error", err)
+	}
+
+	return newIndexingStatus(template, urls[0], pageGroups, pageIndexingStatus{Result: resp, Err: err}), nil
+}
+
+// newIndexingStatus copies the row template and sets the page and its inspection result or error.
+func newIndexingStatus(template *StatusPerURL, url sitemapper.URL, pageGroups []pageGroup, result pageIndexingStatus) StatusPerURL {
+	status := *template
+	status.Loc = url.Loc
+	status.ChangeFreq = url.ChangeFreq
+	status.LastMod = url.LastMod
+	status.Priority = url.Priority
+	status.PageGroup = getPageGroupName(pageGroups, url.Loc)
+	status.UrlInspectionResult = result.Result
+	if result.Err != nil {
+		status.InspectionError = result.Err.Error()
+	}
+	return status
+}
+
+// getIndexingStatusURLs returns the URLs to inspect from the url source, restricted to the
+// given pages if any, along with a row template holding the url source columns. It returns a
+// nil template if there are no URLs to inspect.
+func getIndexingStatusURLs(ctx context.Context, d *plugin.QueryData, pageUrls []string) ([]sitemapper.URL, *StatusPerURL, error) {
+	smUrl, err := getQualStringValue(d, "sitemap_url")
+	if err != nil {
+		plugin.Logger(ctx).Error("googlesearchconsole_indexing_status.getIndexingStatusURLs", "validation_error", err)
+		return nil, nil, err
+	}
+
+	languageCode, err := getQualStringValue(d, "language_code")
+	if err != nil {
+		plugin.Logger(ctx).Error("googlesearchconsole_indexing_status.getIndexingStatusURLs", "validation_error", err)
+		return nil, nil, err
+	}
+
+	urlSource, err := getIndexingStatusUrlSource(d, smUrl, pageUrls)
+	if err != nil {
+		plugin.Logger(ctx).Error("googlesearchconsole_indexing_status.getIndexingStatusURLs", "validation_error", err)
+		return nil, nil, err
+	}
+	if urlSource == "" {
+		return nil, nil, nil
+	}
+
+	template := &StatusPerURL{
+		UrlSource:    urlSource,
+		languageCode: languageCode,
+	}
+
+	var urls []sitemapper.URL
+	switch urlSource {
+	case indexingStatusUrlSourceSitemap:
+		urls, err = getPageInspectionURLs(ctx, d, smUrl, pageUrls)
+		if err != nil {
+			plugin.Logger(ctx).Error("googlesearchconsole_indexing_status.getIndexingStatusURLs", "sitemap_error", err)
+			return nil, nil, err
+		}
+	case indexingStatusUrlSourceLoc:
+		// Only reached from the get call, as the loc qual makes the SDK use it
+		urls, _ = getPageInspectionURLs(ctx, d, "", pageUrls)
+	default:
+		topPages, err := getIndexingStatusTopPages(ctx, d, template)
+		if err != nil {
+			plugin.Logger(ctx).Error("googlesearchconsole_indexing_status.getIndexingStatusURLs", "api_error", err)
+			return nil, nil, err
+		}
+		// Only inspect the top pages that can match the loc qual. The top pages are shared with
+		// other calls, so they are filtered on a copy
+		if len(pageUrls) > 0 {
+			topPages = slices.DeleteFunc(slices.Clone(topPages), func(page string) bool {
+				return !slices.Contains(pageUrls, page)
+			})
+		}
+		urls, _ = getPageInspectionURLs(ctx, d, "", topPages)
+	}
+
+	return urls, template, nil
+}
+
+// getIndexingStatusUrlSource returns the source of the URLs to inspect from the url_source
+// qual, defaulting to the sitemap if set, otherwise the loc qual. It returns an empty source
+// if there are no URLs to inspect.
+func getIndexingStatusUrlSource(d *plugin.QueryData, sitemapUrl string, pageUrls []string) (string, error) {
+	urlSource, err := getQualStringValue(d, "url_source")
+	if err != nil {
+		return "", err
+	}
+	isTopPages := urlSource == indexingStatusUrlSourceTopPagesByClicks || urlSource == indexingStatusUrlSourceTopPagesByImpressions
+
+	switch {
+	case urlSource != "" && !slices.Contains(indexingStatusUrlSources, urlSource):
+		return "", fmt.Errorf("invalid url_source %q, url_source should be one of %v", urlSource, indexingStatusUrlSources)
+	case urlSource == indexingStatusUrlSourceSitemap && sitemapUrl == "":
+		return "", fmt.Errorf("sitemap_url must be specified with url_source %s", urlSource)
+	case urlSource == indexingStatusUrlSourceLoc && len(pageUrls) == 0:
+		return "", fmt.Errorf("loc must be specified with url_source %s", urlSource)
+	case !isTopPages && (d.EqualsQuals["top_pages_limit"] != nil || d.EqualsQuals["start_date"] != nil || d.EqualsQuals["end_date"] != nil):
+		return "", fmt.Errorf("top_pages_limit, start_date and end_date can only be specified with url_source %s or %s", indexingStatusUrlSourceTopPagesByClicks, indexingStatusUrlSourceTopPagesByImpressions)
+	case urlSource != "":
+		return urlSource, nil
+	case sitemapUrl != "":
+		return indexingStatusUrlSourceSitemap, nil
+	case len(pageUrls) > 0:
+		return indexingStatusUrlSourceLoc, nil
+	}
+
+	return "", nil
+}
+
+// getIndexingStatusTopPages returns the URLs of the pages of the site with the most clicks or
+// impressions in the search analytics date range, and sets the request parameters on the template.
+func getIndexingStatusTopPages(ctx context.Context, d *plugin.QueryData, template *StatusPerURL) ([]string, error) {
+	result, err := getIndexingStatusTopPagesMemoized(ctx, d, nil)
 	if err != nil {
-		plugin.Logger(ctx).Error("googlesearchconsole_indexing_status.getIndexingStatus", "api_error", err)
 		return nil, err
 	}
+	topPages := result.(*indexingStatusTopPages)
+
+	template.TopPagesLimit = &topPages.Limit
+	template.StartDate = &topPages.StartDate
+	template.EndDate = &topPages.EndDate
+	return topPages.Pages, nil
+}
+
+type indexingStatusTopPages struct {
+	Pages     []string
+	Limit     int64
+	StartDate time.Time
+	EndDate   time.Time
+}
+
+// The top pages are fetched once and shared by the calls with the same quals, e.g. the get
+// calls of each page of a loc list.
+var getIndexingStatusTopPagesMemoized = plugin.HydrateFunc(getIndexingStatusTopPagesUncached).Memoize(memoize.WithCacheKeyFunction(getIndexingStatusTopPagesCacheKey))
 
-	status := StatusPerURL{
-		Loc:                 pageUrl,
-		UrlInspectionResult: resp,
+// Build a cache key for the call to getIndexingStatusTopPages.
+func getIndexingStatusTopPagesCacheKey(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	urlSource, err := getQualStringValue(d, "url_source")
+	if err != nil {
+		return nil, err
+	}
+	startDate, endDate, err := getSearchAnalyticsDateRange(d, 28)
+	if err != nil {
+		return nil, err
+	}
+	limit, err := getIndexingStatusTopPagesLimit(d)
+	if err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("getIndexingStatusTopPages-%s-%s-%s-%s-%d", d.EqualsQualString("site_url"), urlSource, startDate.Format(searchAnalyticsDateFormat), endDate.Format(searchAnalyticsDateFormat), limit)
+	return key, nil
+}
+
+func getIndexingStatusTopPagesUncached(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	urlSource, err := getQualStringValue(d, "url_source")
+	if err != nil {
+		return nil, err
+	}
+	limit, err := getIndexingStatusTopPagesLimit(d)
+	if err != nil {
+		return nil, err
+	}
+
+	req, searchAnalytics, err := buildSearchAnalyticsRequest(d, []string{"page"}, "final", 28)
+	if err != nil {
+		return nil, err
+	}
+
+	// The API returns the rows by descending clicks, so the top pages by clicks are the first
+	// rows, while the top pages by impressions need all the rows to be sorted
+	fetchLimit := limit
+	if urlSource == indexingStatusUrlSourceTopPagesByImpressions {
+		fetchLimit = -1
+	}
+
+	var rows []*searchconsole.ApiDataRow
+	err = paginateSearchAnalytics(ctx, d, searchAnalytics.SiteUrl, req, fetchLimit, func(_ *searchconsole.SearchAnalyticsQueryResponse, apiRow *searchconsole.ApiDataRow) bool {
+		rows = append(rows, apiRow)
+		return true
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return status, nil
+	if urlSource == indexingStatusUrlSourceTopPagesByImpressions {
+		slices.SortStableFunc(rows, func(a, b *searchconsole.ApiDataRow) int {
+			return cmp.Compare(b.Impressions, a.Impressions)
+		})
+	}
+	if int64(len(rows)) > limit {
+		rows = rows[:limit]
+	}
+
+	topPages := &indexingStatusTopPages{
+		Pages:     make([]string, len(rows)),
+		Limit:     limit,
+		StartDate: searchAnalytics.StartDate,
+		EndDate:   searchAnalytics.EndDate,
+	}
+	for i, row := range rows {
+		topPages.Pages[i] = row.Keys[0]
+	}
+	return topPages, nil
+}
+
+// getIndexingStatusTopPagesLimit returns the number of top pages to inspect from the
+// top_pages_limit qual, defaulting to indexingStatusDefaultTopPagesLimit.
+func getIndexingStatusTopPagesLimit(d *plugin.QueryData) (int64, error) {
+	limit := int64(indexingStatusDefaultTopPagesLimit)
+	if d.EqualsQuals["top_pages_limit"] != nil {
+		limit = d.EqualsQuals["top_pages_limit"].GetInt64Value()
+	}
+	if limit < 1 {
+		return 0, fmt.Errorf("invalid top_pages_limit %d, top_pages_limit should be at least 1", limit)
+	}
+	return limit, nil
 }
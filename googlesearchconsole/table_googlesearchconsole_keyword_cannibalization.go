@@ -0,0 +1,133 @@
+package googlesearchconsole
+
+import (
+	"context"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+//// TABLE DEFINITION
+
+func tableGoogleSearchConsoleKeywordCannibalization(_ context.Context) *plugin.Table {
+	dimensions := []searchAnalyticsDimension{searchAnalyticsQueryDimension, searchAnalyticsPageDimension}
+	return &plugin.Table{
+		Name:        "googlesearchconsole_keyword_cannibalization",
+		Description: "Lists the queries for which two or more pages of a site received impressions, with the search traffic data of each competing page.",
+		List: &plugin.ListConfig{
+			// Filtering on page would leave a single page per query, so only query filters are
+			// passed to the API and page filters are applied to the competing pages afterwards
+			KeyColumns: getSearchAnalyticsKeyColumns([]searchAnalyticsDimension{searchAnalyticsQueryDimension}),
+			Hydrate:    listKeywordCannibalizations,
+		},
+		Columns: append(getSearchAnalyticsColumns(dimensions), []*plugin.Column{
+			{
+				Name:        "competing_pages",
+				Description: "The number of pages that received impressions for the query.",
+				Type:        proto.ColumnType_INT,
+			},
+			{
+				Name:        "query_clicks",
+				Description: "The number of clicks of all the competing pages for the query.",
+				Type:        proto.ColumnType_INT,
+				Transform:   transform.FromField("QueryClicks"),
+			},
+			{
+				Name:        "query_impressions",
+				Description: "The number of impressions of all the competing pages for the query.",
+				Type:        proto.ColumnType_INT,
+				Transform:   transform.FromField("QueryImpressions"),
+			},
+			{
+				Name:        "click_share",
+				Description: "The share of the clicks of the query that went to the page, between 0 and 1. Null if the query received no clicks.",
+				Type:        proto.ColumnType_DOUBLE,
+				Transform:   transform.FromField("ClickShare"),
+			},
+			{
+				Name:        "impression_share",
+				Description: "The share of the impressions of the query that went to the page, between 0 and 1.",
+				Type:        proto.ColumnType_DOUBLE,
+				Transform:   transform.FromField("ImpressionShare"),
+			},
+		}...),
+	}
+}
+
+type KeywordCannibalizationRow struct {
+	SearchAnalyticsRow
+	CompetingPages   int
+	QueryClicks      float64
+	QueryImpressions float64
+	ClickShare       *float64
+	ImpressionShare  float64
+}
+
+//// LIST FUNCTION
+
+func listKeywordCannibalizations(ctx context.Context, d *plugin.QueryData, _ *plugin.HydrateData) (interface{}, error) {
+	if d.EqualsQualString("site_url") == "" {
+		return nil, nil
+	}
+
+	req, template, err := buildSearchAnalyticsRequest(d, []string{"query", "page"}, "final", 28)
+	if err != nil {
+		plugin.Logger(ctx).Error("googlesearchconsole_keyword_cannibalization.listKeywordCannibalizations", "validation_error", err)
+		return nil, err
+	}
+
+	// All the pages of a query are needed before it can be reported, so the whole
+	// query x page data set is fetched first and grouped by query
+	rows, err := getAllSearchAnalyticsRows(ctx, d, req, template)
+	if err != nil {
+		plugin.Logger(ctx).Error("googlesearchconsole_keyword_cannibalization.listKeywordCannibalizations", "api_error", err)
+		return nil, err
+	}
+
+	var queries []string
+	pagesPerQuery := map[string][]*SearchAnalyticsRow{}
+	for _, row := range rows {
+		if _, ok := pagesPerQuery[row.Query]; !ok {
+			queries = append(queries, row.Query)
+		}
+		pagesPerQuery[row.Query] = append(pagesPerQuery[row.Query], row)
+	}
+
+	for _, query := range queries {
+		pages := pagesPerQuery[query]
+		if len(pages) < 2 {
+			continue
+		}
+
+		var queryClicks, queryImpressions float64
+		for _, page := range pages {
+			queryClicks += page.Clicks
+			queryImpressions += page.Impressions
+		}
+
+		for _, page := range pages {
+			row := &KeywordCannibalizationRow{
+				SearchAnalyticsRow: *page,
+				CompetingPages:     len(pages),
+				QueryClicks:        queryClicks,
+				QueryImpressions:   queryImpressions,
+			}
+			if queryClicks > 0 {
+				clickShare := page.Clicks / queryClicks
+				row.ClickShare = &clickShare
+			}
+			if queryImpressions > 0 {
+				row.ImpressionShare = page.Impressions / queryImpressions
+			}
+			d.StreamListItem(ctx, row)
+
+			// Context can be cancelled due to manual cancellation or the limit has been hit
+			if d.RowsRemaining(ctx) == 0 {
+				return nil, nil
+			}
+		}
+	}
+
+	return nil, nil
+}
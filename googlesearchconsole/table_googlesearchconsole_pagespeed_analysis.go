@@ -73,6 +73,11 @@ func getPagespeedAnalysisColumns() []*plugin.Column {
 			Description: "The URL of the page.",
 			Type:        proto.ColumnType_STRING,
 		},
+		{
+			Name:        "page_group",
+			Description: "The name of the first page group of the connection config whose regular expression matches the URL of the page.",
+			Type:        proto.ColumnType_STRING,
+		},
 		{
 			Name:        "analysis_utc_timestamp",
 			Description: "The timestamp of the analysis.",
@@ -206,6 +211,7 @@ func getPagespeedAnalysisColumns() []*plugin.Column {
 type AnalysisPerURL struct {
 	Loc                 string
 	Strategy            string
+	PageGroup           string
 	UrlInspectionResult *pagespeedonline.PagespeedApiPagespeedResponseV5
 }
 
@@ -229,6 +235,12 @@ func listPagespeedAnalyses(ctx context.Context, d *plugin.QueryData, h *plugin.H
 		strategy = "desktop"
 	}
 
+	pageGroups, err := getPageGroups(d)
+	if err != nil {
+		plugin.Logger(ctx).Error("googlesearchconsole_pagespeed_analysis.listPagespeedAnalyses", "validation_error", err)
+		return nil, err
+	}
+
 	sitemapURLs, err := sitemapper.Get(smUrl, nil)
 	if err != nil {
 		plugin.Logger(ctx).Error("googlesearchconsole_pagespeed_analysis.listPagespeedAnalyses", "sitemap_error", err)
@@ -249,6 +261,7 @@ func listPagespeedAnalyses(ctx context.Context, d *plugin.QueryData, h *plugin.H
 		status := AnalysisPerURL{
 			Loc:                 sitemapURL.Loc,
 			Strategy:            strategy,
+			PageGroup:           getPageGroupName(pageGroups, sitemapURL.Loc),
 			UrlInspectionResult: pagespeedAnalysisPerUrl[sitemapURL.Loc],
 		}
 		d.StreamListItem(ctx, status)
@@ -279,6 +292,12 @@ func getPagespeedAnalysis(ctx context.Context, d *plugin.QueryData, h *plugin.Hy
 		strategy = "desktop"
 	}
 
+	pageGroups, err := getPageGroups(d)
+	if err != nil {
+		plugin.Logger(ctx).Error("googlesearchconsole_pagespeed_analysis.getPagespeedAnalysis", "validation_error", err)
+		return nil, err
+	}
+
 	resp, err := getPagespeedAnalysisService(ctx, d, pageUrl, strategy)
 	if err != nil {
 		plugin.Logger(ctx).Error("googlesearchconsole_pagespeed_analysis.getPagespeedAnalysis", "api_error", err)
@@ -288,6 +307,7 @@ func getPagespeedAnalysis(ctx context.Context, d *plugin.QueryData, h *plugin.Hy
 	status := AnalysisPerURL{
 		Loc:                 pageUrl,
 		Strategy:            strategy,
+		PageGroup:           getPageGroupName(pageGroups, pageUrl),
 		UrlInspectionResult: resp,
 	}
 
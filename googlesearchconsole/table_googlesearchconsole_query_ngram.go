@@ -0,0 +1,184 @@
+package googlesearchconsole
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+)
+
+const queryNgramMaxN = 3
+
+//// TABLE DEFINITION
+
+func tableGoogleSearchConsoleQueryNgram(_ context.Context) *plugin.Table {
+	return &plugin.Table{
+		Name:        "googlesearchconsole_query_ngram",
+		Description: "Lists the 1-, 2- and 3-word n-grams of the search queries of a site, with the search traffic data of the queries containing them.",
+		List: &plugin.ListConfig{
+			KeyColumns: append(getSearchAnalyticsKeyColumns(nil), &plugin.KeyColumn{
+				Name:       "n",
+				Require:    plugin.Optional,
+				CacheMatch: "exact",
+			}),
+			Hydrate: listQueryNgrams,
+		},
+		Columns: getQueryNgramColumns(),
+	}
+}
+
+// getQueryNgramColumns returns the search analytics columns without dimensions, with the
+// n-gram columns inserted before the metrics.
+func getQueryNgramColumns() []*plugin.Column {
+	var columns []*plugin.Column
+	for _, column := range getSearchAnalyticsColumns(nil) {
+		switch column.Name {
+		case "clicks":
+			columns = append(columns, []*plugin.Column{
+				{
+					Name:        "n",
+					Description: "The number of words of the n-gram (1, 2 or 3). If not set, n-grams of all sizes are returned.",
+					Type:        proto.ColumnType_INT,
+				},
+				{
+					Name:        "ngram",
+					Description: "The sequence of words found in the queries.",
+					Type:        proto.ColumnType_STRING,
+				},
+				{
+					Name:        "queries",
+					Description: "The number of queries containing the n-gram.",
+					Type:        proto.ColumnType_INT,
+				},
+			}...)
+			column.Description = "The number of clicks of the queries containing the n-gram."
+		case "impressions":
+			column.Description = "The number of impressions of the queries containing the n-gram."
+		case "ctr":
+			column.Description = "The click-through rate of the queries containing the n-gram, between 0 and 1."
+		case "position":
+			column.Description = "The average position of the queries containing the n-gram, weighted by impressions."
+		}
+		columns = append(columns, column)
+	}
+	return columns
+}
+
+type QueryNgramRow struct {
+	SearchAnalyticsRow
+	N       int
+	Ngram   string
+	Queries int
+}
+
+//// LIST FUNCTION
+
+func listQueryNgrams(ctx context.Context, d *plugin.QueryData, _ *plugin.HydrateData) (interface{}, error) {
+	if d.EqualsQualString("site_url") == "" {
+		return nil, nil
+	}
+
+	sizes := []int{1, 2, 3}
+	if d.EqualsQuals["n"] != nil {
+		values := []*proto.QualValue{d.EqualsQuals["n"]}
+		if d.EqualsQuals["n"].GetListValue() != nil {
+			values = d.EqualsQuals["n"].GetListValue().Values
+		}
+		sizes = nil
+		for _, value := range values {
+			n := int(value.GetInt64Value())
+			if n < 1 || n > queryNgramMaxN {
+				err := fmt.Errorf("invalid n %d, n should be 1, 2 or 3", n)
+				plugin.Logger(ctx).Error("googlesearchconsole_query_ngram.listQueryNgrams", "validation_error", err)
+				return nil, err
+			}
+			if !slices.Contains(sizes, n) {
+				sizes = append(sizes, n)
+			}
+		}
+		slices.Sort(sizes)
+	}
+
+	req, template, err := buildSearchAnalyticsRequest(d, []string{"query"}, "final", 28)
+	if err != nil {
+		plugin.Logger(ctx).Error("googlesearchconsole_query_ngram.listQueryNgrams", "validation_error", err)
+		return nil, err
+	}
+
+	rows, err := getAllSearchAnalyticsRows(ctx, d, req, template)
+	if err != nil {
+		plugin.Logger(ctx).Error("googlesearchconsole_query_ngram.listQueryNgrams", "api_error", err)
+		return nil, err
+	}
+
+	for _, n := range sizes {
+		var ngrams []*QueryNgramRow
+		ngramRows := map[string]*QueryNgramRow{}
+		for _, row := range rows {
+			for _, ngram := range getQueryNgrams(row.Query, n) {
+				ngramRow, ok := ngramRows[ngram]
+				if !ok {
+					ngramRow = &QueryNgramRow{
+						SearchAnalyticsRow: *template,
+						N:                  n,
+						Ngram:              ngram,
+					}
+					ngramRow.ResponseAggregationType = row.ResponseAggregationType
+					ngramRows[ngram] = ngramRow
+					ngrams = append(ngrams, ngramRow)
+				}
+				ngramRow.Queries++
+				ngramRow.Clicks += row.Clicks
+				ngramRow.Impressions += row.Impressions
+				// Summed up weighted by impressions, and divided by the total impressions below
+				ngramRow.Position += row.Position * row.Impressions
+			}
+		}
+
+		slices.SortStableFunc(ngrams, func(a, b *QueryNgramRow) int {
+			switch {
+			case a.Impressions > b.Impressions:
+				return -1
+			case a.Impressions < b.Impressions:
+				return 1
+			}
+			return 0
+		})
+
+		for _, ngramRow := range ngrams {
+			if ngramRow.Impressions > 0 {
+				ngramRow.Ctr = ngramRow.Clicks / ngramRow.Impressions
+				ngramRow.Position = ngramRow.Position / ngramRow.Impressions
+			}
+			d.StreamListItem(ctx, ngramRow)
+
+			// Context can be cancelled due to manual cancellation or the limit has been hit
+			if d.RowsRemaining(ctx) == 0 {
+				return nil, nil
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// getQueryNgrams returns the distinct n-grams of n words of the query. Words are the
+// sequences of letters, digits and apostrophes of the lowercased query.
+func getQueryNgrams(query string, n int) []string {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '\'' && r != '’'
+	})
+
+	var ngrams []string
+	for i := 0; i+n <= len(words); i++ {
+		ngram := strings.Join(words[i:i+n], " ")
+		if !slices.Contains(ngrams, ngram) {
+			ngrams = append(ngrams, ngram)
+		}
+	}
+	return ngrams
+}
@@ -0,0 +1,198 @@
+package googlesearchconsole
+
+import (
+	"context"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+//// TABLE DEFINITION
+
+func tableGoogleSearchConsoleRichResultItem(_ context.Context) *plugin.Table {
+	return &plugin.Table{
+		Name:        "googlesearchconsole_rich_result_item",
+		Description: "Lists the rich result items detected by URL inspection, with one row per item issue.",
+		List: &plugin.ListConfig{
+			KeyColumns: []*plugin.KeyColumn{
+				{
+					Name:    "site_url",
+					Require: plugin.Required,
+				},
+				{
+					Name:    "sitemap_url",
+					Require: plugin.Optional,
+				},
+				{
+					Name:    "loc",
+					Require: plugin.Optional,
+				},
+				{
+					Name:       "language_code",
+					Require:    plugin.Optional,
+					CacheMatch: "exact",
+				},
+			},
+			Hydrate: listRichResultItems,
+		},
+		Columns: []*plugin.Column{
+			{
+				Name:        "loc",
+				Description: "The URL of the page.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "site_url",
+				Description: "The URL of the site.",
+				Type:        proto.ColumnType_STRING,
+				Transform:   transform.FromQual("site_url"),
+			},
+			{
+				Name:        "sitemap_url",
+				Description: "The URL of the sitemap.",
+				Type:        proto.ColumnType_STRING,
+				Transform:   transform.FromQual("sitemap_url"),
+			},
+			{
+				Name:        "language_code",
+				Description: "The language of the translated issue messages, as an IETF BCP-47 language code (e.g. en-US or de-CH). Default is English.",
+				Type:        proto.ColumnType_STRING,
+				Transform:   transform.FromQual("language_code"),
+			},
+			{
+				Name:        "page_group",
+				Description: "The name of the first page group of the connection config whose regular expression matches the URL of the page.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "rich_results_verdict",
+				Description: "High level rich results inspection verdict for the URL, based on the most severe issue of its detected items.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "rich_result_type",
+				Description: "The rich result type, e.g. Breadcrumbs or FAQ.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "item_name",
+				Description: "The name of the item, as displayed in the URL inspection tool.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "issue_message",
+				Description: "The message of the issue detected for the item. Null if the item has no issues.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "severity",
+				Description: "The severity of the issue (WARNING or ERROR). Null if the item has no issues.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "project",
+				Description: "The GCP Project associated with the credentials in use.",
+				Type:        proto.ColumnType_STRING,
+				Hydrate:     getProject,
+				Transform:   transform.FromValue(),
+			},
+		},
+	}
+}
+
+type RichResultItem struct {
+	Loc                string
+	PageGroup          string
+	RichResultsVerdict string
+	RichResultType     string
+	ItemName           string
+	IssueMessage       string
+	Severity           string
+}
+
+//// LIST FUNCTION
+
+func listRichResultItems(ctx context.Context, d *plugin.QueryData, _ *plugin.HydrateData) (interface{}, error) {
+	siteUrl := d.EqualsQualString("site_url")
+	pageUrls := getQualStringValues(d, "loc")
+
+	smUrl, err := getQualStringValue(d, "sitemap_url")
+	if err != nil {
+		plugin.Logger(ctx).Error("googlesearchconsole_rich_result_item.listRichResultItems", "validation_error", err)
+		return nil, err
+	}
+
+	languageCode, err := getQualStringValue(d, "language_code")
+	if err != nil {
+		plugin.Logger(ctx).Error("googlesearchconsole_rich_result_item.listRichResultItems", "validation_error", err)
+		return nil, err
+	}
+
+	if smUrl == "" && len(pageUrls) == 0 {
+		plugin.Logger(ctx).Error("googlesearchconsole_rich_result_item.listRichResultItems", "validation_error", "The sitemap_url or loc must be specified.")
+		return nil, nil
+	}
+
+	pageGroups, err := getPageGroups(d)
+	if err != nil {
+		plugin.Logger(ctx).Error("googlesearchconsole_rich_result_item.listRichResultItems", "validation_error", err)
+		return nil, err
+	}
+
+	urls, err := getPageInspectionURLs(ctx, d, smUrl, pageUrls)
+	if err != nil {
+		plugin.Logger(ctx).Error("googlesearchconsole_rich_result_item.listRichResultItems", "sitemap_error", err)
+		return nil, err
+	}
+
+	// Pages that could not be inspected have no detected items, so they are skipped
+	statuses := getPageIndexingStatuses(ctx, d, siteUrl, languageCode, urls)
+	for _, url := range urls {
+		status := statuses[url.Loc].Result
+		if status == nil || status.RichResultsResult == nil {
+			continue
+		}
+
+		template := RichResultItem{
+			Loc:                url.Loc,
+			PageGroup:          getPageGroupName(pageGroups, url.Loc),
+			RichResultsVerdict: status.RichResultsResult.Verdict,
+		}
+
+		// Flatten the detected items into one row per issue, or per item if it has no issues
+		var items []RichResultItem
+		for _, detected := range status.RichResultsResult.DetectedItems {
+			if len(detected.Items) == 0 {
+				row := template
+				row.RichResultType = detected.RichResultType
+				items = append(items, row)
+			}
+			for _, item := range detected.Items {
+				row := template
+				row.RichResultType = detected.RichResultType
+				row.ItemName = item.Name
+				if len(item.Issues) == 0 {
+					items = append(items, row)
+				}
+				for _, issue := range item.Issues {
+					issueRow := row
+					issueRow.IssueMessage = issue.IssueMessage
+					issueRow.Severity = issue.Severity
+					items = append(items, issueRow)
+				}
+			}
+		}
+
+		for _, item := range items {
+			d.StreamListItem(ctx, item)
+
+			// Context can be cancelled due to manual cancellation or the limit has been hit
+			if d.RowsRemaining(ctx) == 0 {
+				return nil, nil
+			}
+		}
+	}
+
+	return nil, nil
+}
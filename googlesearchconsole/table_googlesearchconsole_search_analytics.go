@@ -0,0 +1,904 @@
+package googlesearchconsole
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+	"google.golang.org/api/searchconsole/v1"
+)
+
+//// TABLE DEFINITION
+
+func tableGoogleSearchConsoleSearchAnalytics(_ context.Context) *plugin.Table {
+	return &plugin.Table{
+		Name:        "googlesearchconsole_search_analytics",
+		Description: "Lists the search traffic data (clicks, impressions, CTR and position) of a site.",
+		List: &plugin.ListConfig{
+			KeyColumns: getSearchAnalyticsKeyColumns(searchAnalyticsDimensions),
+			Hydrate:    listSearchAnalytics,
+		},
+		Columns: getSearchAnalyticsColumns(searchAnalyticsDimensions),
+	}
+}
+
+// getSearchAnalyticsKeyColumns returns the search analytics key columns, with a key column
+// for each of the given dimensions that can be filtered on.
+func getSearchAnalyticsKeyColumns(dimensions []searchAnalyticsDimension) []*plugin.KeyColumn {
+	keyColumns := []*plugin.KeyColumn{
+		{
+			Name:    "site_url",
+			Require: plugin.Required,
+		},
+		{
+			Name:       "start_date",
+			Require:    plugin.Optional,
+			CacheMatch: "exact",
+		},
+		{
+			Name:       "end_date",
+			Require:    plugin.Optional,
+			CacheMatch: "exact",
+		},
+		{
+			Name:       "search_type",
+			Require:    plugin.Optional,
+			CacheMatch: "exact",
+		},
+		{
+			Name:       "data_state",
+			Require:    plugin.Optional,
+			CacheMatch: "exact",
+		},
+		{
+			Name:       "aggregation_type",
+			Require:    plugin.Optional,
+			CacheMatch: "exact",
+		},
+	}
+
+	for _, dim := range dimensions {
+		if dim.Filterable {
+			keyColumns = append(keyColumns, &plugin.KeyColumn{
+				Name:      dim.Column,
+				Require:   plugin.Optional,
+				Operators: []string{"=", "<>", "~~", "!~~", "~~*", "!~~*", "~", "!~", "~*", "!~*"},
+			})
+		}
+		// Alpha-2 codes are translated to the alpha-3 codes the API filters on
+		if dim.Column == "country" {
+			keyColumns = append(keyColumns, &plugin.KeyColumn{
+				Name:      "country_code_alpha2",
+				Require:   plugin.Optional,
+				Operators: []string{"=", "<>"},
+			})
+		}
+	}
+
+	return keyColumns
+}
+
+// getSearchAnalyticsColumns returns the search analytics columns, with a column for each of
+// the given dimensions.
+func getSearchAnalyticsColumns(dimensions []searchAnalyticsDimension) []*plugin.Column {
+	columns := []*plugin.Column{
+		{
+			Name:        "site_url",
+			Description: "The URL of the site.",
+			Type:        proto.ColumnType_STRING,
+		},
+		{
+			Name:        "start_date",
+			Description: "Start date of the requested date range, in PT (UTC - 8:00). Defaults to 28 days before end_date.",
+			Type:        proto.ColumnType_TIMESTAMP,
+		},
+		{
+			Name:        "end_date",
+			Description: "End date of the requested date range, in PT (UTC - 8:00). Defaults to the current date.",
+			Type:        proto.ColumnType_TIMESTAMP,
+		},
+		{
+			Name:        "search_type",
+			Description: "The search type (web, image, video, news, discover or googleNews). Default is web.",
+			Type:        proto.ColumnType_STRING,
+		},
+		{
+			Name:        "data_state",
+			Description: "The data state (final, all or hourly_all). final only includes finalized data, all and hourly_all also include fresh data that may still change. Default is final.",
+			Type:        proto.ColumnType_STRING,
+		},
+		{
+			Name:        "aggregation_type",
+			Description: "The requested aggregation type (auto, byPage, byProperty or byNewsShowcasePanel). Default is auto.",
+			Type:        proto.ColumnType_STRING,
+		},
+		{
+			Name:        "response_aggregation_type",
+			Description: "How the results were aggregated (byPage, byProperty or byNewsShowcasePanel). Clicks and impressions aggregated by page and by property give different totals.",
+			Type:        proto.ColumnType_STRING,
+		},
+	}
+
+	for _, dim := range dimensions {
+		columns = append(columns, &plugin.Column{
+			Name:        dim.Column,
+			Description: dim.Description,
+			Type:        dim.Type,
+		})
+		switch dim.Column {
+		case "query":
+			columns = append(columns, &plugin.Column{
+				Name:        "is_branded",
+				Description: "True if the query matches one of the brand_terms regular expressions of the connection config. Null if no brand terms are configured.",
+				Type:        proto.ColumnType_BOOL,
+				Transform:   transform.FromField("IsBranded"),
+			})
+		case "page":
+			columns = append(columns, &plugin.Column{
+				Name:        "page_group",
+				Description: "The name of the first page group of the connection config whose regular expression matches the page.",
+				Type:        proto.ColumnType_STRING,
+			})
+		case "country":
+			columns = append(columns, []*plugin.Column{
+				{
+					Name:        "country_code_alpha2",
+					Description: "The country from which the search was made, as an uppercase ISO 3166-1 alpha-2 code.",
+					Type:        proto.ColumnType_STRING,
+				},
+				{
+					Name:        "country_name",
+					Description: "The name of the country from which the search was made.",
+					Type:        proto.ColumnType_STRING,
+				},
+			}...)
+		}
+	}
+
+	return append(columns, []*plugin.Column{
+		{
+			Name:        "clicks",
+			Description: "The number of clicks.",
+			Type:        proto.ColumnType_INT,
+			Transform:   transform.FromField("Clicks"),
+		},
+		{
+			Name:        "impressions",
+			Description: "The number of impressions.",
+			Type:        proto.ColumnType_INT,
+			Transform:   transform.FromField("Impressions"),
+		},
+		{
+			Name:        "ctr",
+			Description: "The click-through rate, between 0 and 1.",
+			Type:        proto.ColumnType_DOUBLE,
+			Transform:   transform.FromField("Ctr"),
+		},
+		{
+			Name:        "position",
+			Description: "The average position in the search results.",
+			Type:        proto.ColumnType_DOUBLE,
+			Transform:   transform.FromField("Position"),
+		},
+		{
+			Name:        "project",
+			Description: "The GCP Project associated with the credentials in use.",
+			Type:        proto.ColumnType_STRING,
+			Hydrate:     getProject,
+			Transform:   transform.FromValue(),
+		},
+	}...)
+}
+
+type SearchAnalyticsRow struct {
+	SiteUrl                 string
+	StartDate               time.Time
+	EndDate                 time.Time
+	SearchType              string
+	DataState               string
+	AggregationType         string
+	ResponseAggregationType string
+	Query                   string
+	IsBranded               *bool
+	Page                    string
+	PageGroup               string
+	Country                 string
+	CountryCodeAlpha2       string
+	CountryName             string
+	Device                  string
+	Date                    time.Time
+	Hour                    time.Time
+	SearchAppearance        string
+	Clicks                  float64
+	Impressions             float64
+	Ctr                     float64
+	Position                float64
+
+	brandTerms []*regexp.Regexp
+	pageGroups []pageGroup
+}
+
+// searchAnalyticsDimension maps a table column to the matching Search Analytics API dimension.
+// Filterable dimensions can be used in the dimension filter groups of a request.
+type searchAnalyticsDimension struct {
+	Column      string
+	Dimension   string
+	Filterable  bool
+	Description string
+	Type        proto.ColumnType
+}
+
+var (
+	searchAnalyticsDateDimension = searchAnalyticsDimension{
+		Column:      "date",
+		Dimension:   "date",
+		Description: "The day on which the search was made, in PT (UTC - 8:00).",
+		Type:        proto.ColumnType_TIMESTAMP,
+	}
+	searchAnalyticsQueryDimension = searchAnalyticsDimension{
+		Column:      "query",
+		Dimension:   "query",
+		Filterable:  true,
+		Description: "The search query.",
+		Type:        proto.ColumnType_STRING,
+	}
+	searchAnalyticsPageDimension = searchAnalyticsDimension{
+		Column:      "page",
+		Dimension:   "page",
+		Filterable:  true,
+		Description: "The canonical URL of the page shown in the search results.",
+		Type:        proto.ColumnType_STRING,
+	}
+	searchAnalyticsCountryDimension = searchAnalyticsDimension{
+		Column:      "country",
+		Dimension:   "country",
+		Filterable:  true,
+		Description: "The country from which the search was made, as a lowercase ISO 3166-1 alpha-3 code.",
+		Type:        proto.ColumnType_STRING,
+	}
+	searchAnalyticsDeviceDimension = searchAnalyticsDimension{
+		Column:      "device",
+		Dimension:   "device",
+		Filterable:  true,
+		Description: "The type of device on which the search was made (DESKTOP, MOBILE or TABLET).",
+		Type:        proto.ColumnType_STRING,
+	}
+	searchAnalyticsSearchAppearanceDimension = searchAnalyticsDimension{
+		Column:      "search_appearance",
+		Dimension:   "searchAppearance",
+		Filterable:  true,
+		Description: "The search result feature (rich result type) in which the page appeared. Only populated when no other dimension column is selected, or when filtered on a single value.",
+		Type:        proto.ColumnType_STRING,
+	}
+
+	// searchAnalyticsDimensions are all the dimensions, in the order they are requested from the API
+	searchAnalyticsDimensions = []searchAnalyticsDimension{
+		searchAnalyticsDateDimension,
+		searchAnalyticsQueryDimension,
+		searchAnalyticsPageDimension,
+		searchAnalyticsCountryDimension,
+		searchAnalyticsDeviceDimension,
+		searchAnalyticsSearchAppearanceDimension,
+	}
+)
+
+var (
+	searchAnalyticsSearchTypes      = []string{"web", "image", "video", "news", "discover", "googleNews"}
+	searchAnalyticsDataStates       = []string{"final", "all", "hourly_all"}
+	searchAnalyticsAggregationTypes = []string{"auto", "byPage", "byProperty", "byNewsShowcasePanel"}
+)
+
+const (
+	searchAnalyticsDateFormat = "2006-01-02"
+
+	// searchAnalyticsMaxRowLimit is the maximum number of rows the API returns per request
+	searchAnalyticsMaxRowLimit = 25000
+
+	// searchAnalyticsMaxConcurrentDays is the maximum number of days fetched in parallel
+	// when a date range is split into per-day requests
+	searchAnalyticsMaxConcurrentDays = 5
+)
+
+//// LIST FUNCTION
+
+func listSearchAnalytics(ctx context.Context, d *plugin.QueryData, _ *plugin.HydrateData) (interface{}, error) {
+	if d.EqualsQualString("site_url") == "" {
+		return nil, nil
+	}
+
+	req, template, err := buildSearchAnalyticsRequest(d, getSearchAnalyticsRequestedDimensions(d), "final", 28)
+	if err != nil {
+		plugin.Logger(ctx).Error("googlesearchconsole_search_analytics.listSearchAnalytics", "validation_error", err)
+		return nil, err
+	}
+
+	// Per-day requests return more long-tail rows than a single request for the whole range
+	gscConfig := GetConfig(d.Connection)
+	if gscConfig.SearchAnalyticsSplitByDay != nil && *gscConfig.SearchAnalyticsSplitByDay {
+		err = streamSearchAnalyticsByDay(ctx, d, req, template)
+	} else {
+		err = streamSearchAnalytics(ctx, d, req, template)
+	}
+	if err != nil {
+		plugin.Logger(ctx).Error("googlesearchconsole_search_analytics.listSearchAnalytics", "api_error", err)
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// listSearchAnalyticsByDimension returns a list function which always requests exactly the
+// given dimension, so that its totals are not affected by the row limits of combined dimensions.
+func listSearchAnalyticsByDimension(dimension searchAnalyticsDimension) plugin.HydrateFunc {
+	return func(ctx context.Context, d *plugin.QueryData, _ *plugin.HydrateData) (interface{}, error) {
+		if d.EqualsQualString("site_url") == "" {
+			return nil, nil
+		}
+
+		req, template, err := buildSearchAnalyticsRequest(d, []string{dimension.Dimension}, "final", 28)
+		if err != nil {
+			plugin.Logger(ctx).Error("googlesearchconsole_search_analytics.listSearchAnalyticsByDimension", "validation_error", err)
+			return nil, err
+		}
+
+		err = streamSearchAnalytics(ctx, d, req, template)
+		if err != nil {
+			plugin.Logger(ctx).Error("googlesearchconsole_search_analytics.listSearchAnalyticsByDimension", "api_error", err)
+			return nil, err
+		}
+
+		return nil, nil
+	}
+}
+
+// buildSearchAnalyticsRequest validates the quals and builds the API request for the given
+// dimensions, along with a row template holding the request-level column values.
+// defaultDataState and defaultDays apply when the data_state and start_date quals are not set.
+func buildSearchAnalyticsRequest(d *plugin.QueryData, dimensions []string, defaultDataState string, defaultDays int) (*searchconsole.SearchAnalyticsQueryRequest, *SearchAnalyticsRow, error) {
+	startDate, endDate, err := getSearchAnalyticsDateRange(d, defaultDays)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	searchType, err := getSearchAnalyticsSearchType(d)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dataState, err := getSearchAnalyticsDataState(d, defaultDataState)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := validateSearchAnalyticsCountryQuals(d); err != nil {
+		return nil, nil, err
+	}
+
+	req := &searchconsole.SearchAnalyticsQueryRequest{
+		StartDate:             startDate.Format(searchAnalyticsDateFormat),
+		EndDate:               endDate.Format(searchAnalyticsDateFormat),
+		Type:                  searchType,
+		DataState:             dataState,
+		Dimensions:            dimensions,
+		DimensionFilterGroups: getSearchAnalyticsDimensionFilterGroups(d),
+	}
+
+	aggregationType, err := getSearchAnalyticsAggregationType(d, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.AggregationType = aggregationType
+
+	brandTerms, err := getBrandTerms(d)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pageGroups, err := getPageGroups(d)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	template := &SearchAnalyticsRow{
+		SiteUrl:         d.EqualsQualString("site_url"),
+		StartDate:       startDate,
+		EndDate:         endDate,
+		SearchType:      searchType,
+		DataState:       dataState,
+		AggregationType: aggregationType,
+		brandTerms:      brandTerms,
+		pageGroups:      pageGroups,
+	}
+	// The search appearance is not grouped by alongside other dimensions, but
+	// every row matches it when it was filtered on a single value
+	if d.EqualsQuals["search_appearance"] != nil {
+		template.SearchAppearance = d.EqualsQuals["search_appearance"].GetStringValue()
+	}
+
+	return req, template, nil
+}
+
+// streamSearchAnalytics fetches all pages of the request and streams a row for each result,
+// stopping early once the query limit has been reached.
+func streamSearchAnalytics(ctx context.Context, d *plugin.QueryData, req *searchconsole.SearchAnalyticsQueryRequest, template *SearchAnalyticsRow) error {
+	return paginateSearchAnalytics(ctx, d, template.SiteUrl, req, d.QueryContext.GetLimit(), func(resp *searchconsole.SearchAnalyticsQueryResponse, apiRow *searchconsole.ApiDataRow) bool {
+		row := newSearchAnalyticsRow(template, req.Dimensions, apiRow)
+		row.ResponseAggregationType = resp.ResponseAggregationType
+		d.StreamListItem(ctx, row)
+
+		// Context can be cancelled due to manual cancellation or the limit has been hit
+		return d.RowsRemaining(ctx) > 0
+	})
+}
+
+// getAllSearchAnalyticsRows fetches all pages of the request, for tables which need the whole
+// data set before they can compute their rows.
+func getAllSearchAnalyticsRows(ctx context.Context, d *plugin.QueryData, req *searchconsole.SearchAnalyticsQueryRequest, template *SearchAnalyticsRow) ([]*SearchAnalyticsRow, error) {
+	var rows []*SearchAnalyticsRow
+	err := paginateSearchAnalytics(ctx, d, template.SiteUrl, req, -1, func(resp *searchconsole.SearchAnalyticsQueryResponse, apiRow *searchconsole.ApiDataRow) bool {
+		row := newSearchAnalyticsRow(template, req.Dimensions, apiRow)
+		row.ResponseAggregationType = resp.ResponseAggregationType
+		rows = append(rows, row)
+		return true
+	})
+	return rows, err
+}
+
+type searchAnalyticsDayResult struct {
+	Rows []*SearchAnalyticsRow
+	Err  error
+}
+
+// streamSearchAnalyticsByDay splits the date range of the request into per-day requests,
+// fetches up to searchAnalyticsMaxConcurrentDays days in parallel, and streams the rows of
+// each day in date order. Every row has its date set, even if date is not a requested dimension.
+func streamSearchAnalyticsByDay(ctx context.Context, d *plugin.QueryData, req *searchconsole.SearchAnalyticsQueryRequest, template *SearchAnalyticsRow) error {
+	var days []time.Time
+	for day := template.StartDate; !day.After(template.EndDate); day = day.AddDate(0, 0, 1) {
+		days = append(days, day)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]chan searchAnalyticsDayResult, len(days))
+	for i := range results {
+		results[i] = make(chan searchAnalyticsDayResult, 1)
+	}
+
+	// A slot is taken when a day is started and only freed once the day's result has been
+	// read below, so at most searchAnalyticsMaxConcurrentDays days are fetched or waiting.
+	sem := make(chan struct{}, searchAnalyticsMaxConcurrentDays)
+	go func() {
+		for i, day := range days {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			if d.RowsRemaining(ctx) == 0 {
+				return
+			}
+			go func(day time.Time, result chan<- searchAnalyticsDayResult) {
+				dayReq := *req
+				dayReq.StartDate = day.Format(searchAnalyticsDateFormat)
+				dayReq.EndDate = dayReq.StartDate
+
+				var rows []*SearchAnalyticsRow
+				err := paginateSearchAnalytics(ctx, d, template.SiteUrl, &dayReq, d.QueryContext.GetLimit(), func(resp *searchconsole.SearchAnalyticsQueryResponse, apiRow *searchconsole.ApiDataRow) bool {
+					row := newSearchAnalyticsRow(template, dayReq.Dimensions, apiRow)
+					row.ResponseAggregationType = resp.ResponseAggregationType
+					row.Date = day
+					rows = append(rows, row)
+					return true
+				})
+				result <- searchAnalyticsDayResult{Rows: rows, Err: err}
+			}(day, results[i])
+		}
+	}()
+
+	for i, day := range days {
+		var result searchAnalyticsDayResult
+		select {
+		case result = <-results[i]:
+		case <-ctx.Done():
+			return nil
+		}
+		<-sem
+		if result.Err != nil {
+			plugin.Logger(ctx).Error("streamSearchAnalyticsByDay", "day", day.Format(searchAnalyticsDateFormat), "api_error", result.Err)
+			return result.Err
+		}
+
+		for _, row := range result.Rows {
+			d.StreamListItem(ctx, row)
+
+			// Context can be cancelled due to manual cancellation or the limit has been hit
+			if d.RowsRemaining(ctx) == 0 {
+				return nil
+			}
+		}
+	}
+
+	return nil
+}
+
+// paginateSearchAnalytics runs the query page by page, walking startRow, and calls fn for
+// each returned row, along with the response it belongs to. It stops when the API returns a short page, when limit rows have been
+// fetched (a negative limit means no limit), when fn returns false, or when the context is
+// cancelled.
+func paginateSearchAnalytics(ctx context.Context, d *plugin.QueryData, siteUrl string, req *searchconsole.SearchAnalyticsQueryRequest, limit int64, fn func(*searchconsole.SearchAnalyticsQueryResponse, *searchconsole.ApiDataRow) bool) error {
+	var fetched int64
+	for {
+		if plugin.IsCancelled(ctx) {
+			return nil
+		}
+
+		req.StartRow = fetched
+		req.RowLimit = searchAnalyticsMaxRowLimit
+		if limit >= 0 && limit-fetched < req.RowLimit {
+			req.RowLimit = limit - fetched
+		}
+		if req.RowLimit <= 0 {
+			return nil
+		}
+
+		resp, err := getSearchAnalyticsService(ctx, d, siteUrl, req)
+		if err != nil {
+			return err
+		}
+
+		for _, apiRow := range resp.Rows {
+			if !fn(resp, apiRow) {
+				return nil
+			}
+		}
+
+		fetched += int64(len(resp.Rows))
+		if int64(len(resp.Rows)) < req.RowLimit {
+			return nil
+		}
+	}
+}
+
+// getSearchAnalyticsDateRange returns the date range from the start_date and end_date quals,
+// defaulting to the last defaultDays days.
+func getSearchAnalyticsDateRange(d *plugin.QueryData, defaultDays int) (time.Time, time.Time, error) {
+	endDate := time.Now().UTC().Truncate(24 * time.Hour)
+	if d.EqualsQuals["end_date"] != nil {
+		endDate = d.EqualsQuals["end_date"].GetTimestampValue().AsTime()
+	}
+
+	startDate := endDate.AddDate(0, 0, -defaultDays)
+	if d.EqualsQuals["start_date"] != nil {
+		startDate = d.EqualsQuals["start_date"].GetTimestampValue().AsTime()
+	}
+
+	if startDate.After(endDate) {
+		return startDate, endDate, fmt.Errorf("start_date %s must not be after end_date %s", startDate.Format(searchAnalyticsDateFormat), endDate.Format(searchAnalyticsDateFormat))
+	}
+
+	return startDate, endDate, nil
+}
+
+// getSearchAnalyticsSearchType returns the API search type from the search_type qual,
+// defaulting to web.
+func getSearchAnalyticsSearchType(d *plugin.QueryData) (string, error) {
+	searchType, err := getQualStringValue(d, "search_type")
+	if err != nil {
+		return "", err
+	}
+	if searchType == "" {
+		return "web", nil
+	}
+
+	if !slices.Contains(searchAnalyticsSearchTypes, searchType) {
+		return "", fmt.Errorf("invalid search_type %q, the search_type should be one of 'web', 'image', 'video', 'news', 'discover' or 'googleNews'", searchType)
+	}
+
+	return searchType, nil
+}
+
+// getSearchAnalyticsDataState returns the API data state from the data_state qual,
+// defaulting to defaultDataState.
+func getSearchAnalyticsDataState(d *plugin.QueryData, defaultDataState string) (string, error) {
+	dataState, err := getQualStringValue(d, "data_state")
+	if err != nil {
+		return "", err
+	}
+	if dataState == "" {
+		return defaultDataState, nil
+	}
+
+	if !slices.Contains(searchAnalyticsDataStates, dataState) {
+		return "", fmt.Errorf("invalid data_state %q, the data_state should be one of 'final', 'all' or 'hourly_all'", dataState)
+	}
+
+	return dataState, nil
+}
+
+// validateSearchAnalyticsCountryQuals rejects two-letter values in country = and <> quals. The
+// column holds alpha-3 codes, so an alpha-2 code such as 'US' would silently match no rows.
+func validateSearchAnalyticsCountryQuals(d *plugin.QueryData) error {
+	if d.Quals["country"] == nil {
+		return nil
+	}
+	for _, q := range d.Quals["country"].Quals {
+		if q.Operator != "=" && q.Operator != "<>" {
+			continue
+		}
+		values := []*proto.QualValue{q.Value}
+		if list := q.Value.GetListValue(); list != nil {
+			values = list.Values
+		}
+		for _, v := range values {
+			if value := v.GetStringValue(); len(value) == 2 {
+				return fmt.Errorf("invalid country %q, the country column holds lowercase ISO 3166-1 alpha-3 codes such as 'usa', use the country_code_alpha2 column to filter on alpha-2 codes such as 'US'", value)
+			}
+		}
+	}
+	return nil
+}
+
+// getSearchAnalyticsAggregationType returns the API aggregation type from the aggregation_type
+// qual, defaulting to auto. Combinations the API rejects are reported as validation errors.
+func getSearchAnalyticsAggregationType(d *plugin.QueryData, req *searchconsole.SearchAnalyticsQueryRequest) (string, error) {
+	aggregationType, err := getQualStringValue(d, "aggregation_type")
+	if err != nil {
+		return "", err
+	}
+	if aggregationType == "" {
+		return "auto", nil
+	}
+
+	if !slices.Contains(searchAnalyticsAggregationTypes, aggregationType) {
+		return "", fmt.Errorf("invalid aggregation_type %q, the aggregation_type should be one of 'auto', 'byPage', 'byProperty' or 'byNewsShowcasePanel'", aggregationType)
+	}
+
+	if aggregationType == "byProperty" || aggregationType == "byNewsShowcasePanel" {
+		if slices.Contains(req.Dimensions, "page") {
+			return "", fmt.Errorf("aggregation_type %q cannot be used when results are grouped by page, use 'auto' or 'byPage'", aggregationType)
+		}
+		for _, group := range req.DimensionFilterGroups {
+			for _, filter := range group.Filters {
+				if filter.Dimension == "page" {
+					return "", fmt.Errorf("aggregation_type %q cannot be used when results are filtered by page, use 'auto' or 'byPage'", aggregationType)
+				}
+			}
+		}
+	}
+
+	if aggregationType == "byNewsShowcasePanel" && req.Type != "discover" && req.Type != "googleNews" {
+		return "", fmt.Errorf("aggregation_type 'byNewsShowcasePanel' can only be used with the 'discover' or 'googleNews' search_type")
+	}
+
+	return aggregationType, nil
+}
+
+// getSearchAnalyticsRequestedDimensions returns the API dimensions for the dimension columns
+// requested by the query. The searchAppearance dimension cannot be combined with
+// other dimensions, so it is only requested on its own.
+func getSearchAnalyticsRequestedDimensions(d *plugin.QueryData) []string {
+	var dimensions []string
+	for _, dim := range searchAnalyticsDimensions {
+		if dim.Column != "search_appearance" && isSearchAnalyticsDimensionSelected(d, dim) {
+			dimensions = append(dimensions, dim.Dimension)
+		}
+	}
+
+	if len(dimensions) == 0 && slices.Contains(d.QueryContext.Columns, "search_appearance") {
+		dimensions = append(dimensions, "searchAppearance")
+	}
+
+	return dimensions
+}
+
+// isSearchAnalyticsDimensionSelected returns whether the column of the dimension, or a column
+// derived from it by the plugin (is_branded from query, page_group from page, country_code_alpha2
+// and country_name from country), is selected.
+func isSearchAnalyticsDimensionSelected(d *plugin.QueryData, dim searchAnalyticsDimension) bool {
+	columns := []string{dim.Column}
+	switch dim.Column {
+	case "query":
+		columns = append(columns, "is_branded")
+	case "page":
+		columns = append(columns, "page_group")
+	case "country":
+		columns = append(columns, "country_code_alpha2", "country_name")
+	}
+	return slices.ContainsFunc(columns, func(column string) bool {
+		return slices.Contains(d.QueryContext.Columns, column)
+	})
+}
+
+// getSearchAnalyticsDimensionFilterGroups translates the quals on the filterable dimension
+// columns into a single AND'ed group of API dimension filters. The filters never exclude rows
+// that match the quals, and Postgres narrows down any superset they return.
+func getSearchAnalyticsDimensionFilterGroups(d *plugin.QueryData) []*searchconsole.ApiDimensionFilterGroup {
+	var filters []*searchconsole.ApiDimensionFilter
+
+	for _, dim := range searchAnalyticsDimensions {
+		if !dim.Filterable || d.Quals[dim.Column] == nil {
+			continue
+		}
+		for _, q := range d.Quals[dim.Column].Quals {
+			filter := getSearchAnalyticsDimensionFilter(dim.Dimension, q.Operator, q.Value)
+			if filter != nil {
+				filters = append(filters, filter)
+			}
+		}
+	}
+
+	if d.Quals["country_code_alpha2"] != nil {
+		for _, q := range d.Quals["country_code_alpha2"].Quals {
+			value, ok := getSearchAnalyticsCountryQualValue(q.Value)
+			if !ok {
+				continue
+			}
+			filter := getSearchAnalyticsDimensionFilter(searchAnalyticsCountryDimension.Dimension, q.Operator, value)
+			if filter != nil {
+				filters = append(filters, filter)
+			}
+		}
+	}
+
+	if len(filters) == 0 {
+		return nil
+	}
+
+	return []*searchconsole.ApiDimensionFilterGroup{
+		{
+			GroupType: "and",
+			Filters:   filters,
+		},
+	}
+}
+
+// getSearchAnalyticsCountryQualValue returns the value of a country_code_alpha2 qual with the
+// ISO 3166-1 alpha-2 codes replaced by the alpha-3 codes used by the API. Unknown or lowercase
+// codes match no rows, so they are left out, and false is returned if no code is left.
+func getSearchAnalyticsCountryQualValue(value *proto.QualValue) (*proto.QualValue, bool) {
+	if list := value.GetListValue(); list != nil {
+		var values []*proto.QualValue
+		for _, v := range list.Values {
+			if alpha3, ok := getSearchAnalyticsCountryQualValue(v); ok {
+				values = append(values, alpha3)
+			}
+		}
+		return &proto.QualValue{Value: &proto.QualValue_ListValue{ListValue: &proto.QualValueList{Values: values}}}, len(values) > 0
+	}
+	// The column holds uppercase codes, so other values must not be translated for <>
+	alpha3, ok := countryAlpha3ByAlpha2[value.GetStringValue()]
+	if !ok {
+		return nil, false
+	}
+	return &proto.QualValue{Value: &proto.QualValue_StringValue{StringValue: alpha3}}, true
+}
+
+// getSearchAnalyticsDimensionFilter returns the API dimension filter matching the given
+// qual operator and value, or nil if the qual cannot be pushed down.
+func getSearchAnalyticsDimensionFilter(dimension string, operator string, value *proto.QualValue) *searchconsole.ApiDimensionFilter {
+	// A list of values (e.g. "in" or a join) is matched with an anchored alternation
+	if list := value.GetListValue(); list != nil {
+		if operator != "=" {
+			return nil
+		}
+		var values []string
+		for _, v := range list.Values {
+			values = append(values, regexp.QuoteMeta(v.GetStringValue()))
+		}
+		if len(values) == 0 {
+			return nil
+		}
+		return &searchconsole.ApiDimensionFilter{
+			Dimension:  dimension,
+			Operator:   "includingRegex",
+			Expression: "^(" + strings.Join(values, "|") + ")$",
+		}
+	}
+
+	// Only the query and page dimensions are compared case-sensitively by the API, so negated
+	// filters on the other dimensions could exclude rows that match the quals
+	negated := operator == "<>" || strings.HasPrefix(operator, "!")
+	if negated && dimension != "query" && dimension != "page" {
+		return nil
+	}
+
+	expression := value.GetStringValue()
+	filter := &searchconsole.ApiDimensionFilter{
+		Dimension:  dimension,
+		Expression: expression,
+	}
+
+	switch operator {
+	case "=":
+		filter.Operator = "equals"
+	case "<>":
+		filter.Operator = "notEquals"
+	case "~", "~*", "!~", "!~*":
+		// The regular expressions are case-sensitive RE2, unless prefixed with (?i)
+		filter.Operator = "includingRegex"
+		if strings.HasPrefix(operator, "!") {
+			filter.Operator = "excludingRegex"
+		}
+		if strings.HasSuffix(operator, "*") {
+			filter.Expression = "(?i)" + expression
+		}
+	case "~~", "~~*", "!~~", "!~~*":
+		negate := strings.HasPrefix(operator, "!")
+		insensitive := strings.HasSuffix(operator, "*")
+		if literal, ok := likePatternLiteral(expression); ok && !insensitive {
+			// equals and notEquals are case-sensitive
+			filter.Expression = literal
+			filter.Operator = "equals"
+			if negate {
+				filter.Operator = "notEquals"
+			}
+		} else if literal, ok := likePatternContains(expression); ok && !negate {
+			// contains is case-insensitive, so it returns a superset of the rows for ~~
+			filter.Expression = literal
+			filter.Operator = "contains"
+		} else {
+			// notContains is case-insensitive too, so it would drop rows that !~~ keeps
+			filter.Expression = likePatternToRegex(expression)
+			if insensitive {
+				filter.Expression = "(?i)" + filter.Expression
+			}
+			filter.Operator = "includingRegex"
+			if negate {
+				filter.Operator = "excludingRegex"
+			}
+		}
+	default:
+		return nil
+	}
+
+	return filter
+}
+
+// newSearchAnalyticsRow copies the row template and maps the keys of an API row, which are
+// in the order of the requested dimensions, to the matching fields.
+func newSearchAnalyticsRow(template *SearchAnalyticsRow, dimensions []string, apiRow *searchconsole.ApiDataRow) *SearchAnalyticsRow {
+	row := *template
+	row.Clicks = apiRow.Clicks
+	row.Impressions = apiRow.Impressions
+	row.Ctr = apiRow.Ctr
+	row.Position = apiRow.Position
+
+	for i, dimension := range dimensions {
+		if i >= len(apiRow.Keys) {
+			break
+		}
+		key := apiRow.Keys[i]
+		switch dimension {
+		case "date":
+			row.Date, _ = time.Parse(searchAnalyticsDateFormat, key)
+		case "hour":
+			row.Hour, _ = time.Parse(time.RFC3339, key)
+		case "query":
+			row.Query = key
+			row.IsBranded = isBrandedQuery(template.brandTerms, key)
+		case "page":
+			row.Page = key
+			row.PageGroup = getPageGroupName(template.pageGroups, key)
+		case "country":
+			row.Country = key
+			if c, ok := countriesByAlpha3[key]; ok {
+				row.CountryCodeAlpha2 = c.Alpha2
+				row.CountryName = c.Name
+			}
+		case "device":
+			row.Device = key
+		case "searchAppearance":
+			row.SearchAppearance = key
+		}
+	}
+
+	return &row
+}
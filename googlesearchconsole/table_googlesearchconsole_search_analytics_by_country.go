@@ -0,0 +1,23 @@
+package googlesearchconsole
+
+import (
+	"context"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+)
+
+//// TABLE DEFINITION
+
+func tableGoogleSearchConsoleSearchAnalyticsByCountry(_ context.Context) *plugin.Table {
+	dimensions := []searchAnalyticsDimension{searchAnalyticsCountryDimension}
+
+	return &plugin.Table{
+		Name:        "googlesearchconsole_search_analytics_by_country",
+		Description: "Lists the search traffic data (clicks, impressions, CTR and position) of a site per country.",
+		List: &plugin.ListConfig{
+			KeyColumns: getSearchAnalyticsKeyColumns(dimensions),
+			Hydrate:    listSearchAnalyticsByDimension(searchAnalyticsCountryDimension),
+		},
+		Columns: getSearchAnalyticsColumns(dimensions),
+	}
+}
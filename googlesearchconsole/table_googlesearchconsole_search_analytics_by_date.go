@@ -0,0 +1,23 @@
+package googlesearchconsole
+
+import (
+	"context"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+)
+
+//// TABLE DEFINITION
+
+func tableGoogleSearchConsoleSearchAnalyticsByDate(_ context.Context) *plugin.Table {
+	dimensions := []searchAnalyticsDimension{searchAnalyticsDateDimension}
+
+	return &plugin.Table{
+		Name:        "googlesearchconsole_search_analytics_by_date",
+		Description: "Lists the search traffic data (clicks, impressions, CTR and position) of a site per day.",
+		List: &plugin.ListConfig{
+			KeyColumns: getSearchAnalyticsKeyColumns(dimensions),
+			Hydrate:    listSearchAnalyticsByDimension(searchAnalyticsDateDimension),
+		},
+		Columns: getSearchAnalyticsColumns(dimensions),
+	}
+}
@@ -0,0 +1,23 @@
+package googlesearchconsole
+
+import (
+	"context"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+)
+
+//// TABLE DEFINITION
+
+func tableGoogleSearchConsoleSearchAnalyticsByDevice(_ context.Context) *plugin.Table {
+	dimensions := []searchAnalyticsDimension{searchAnalyticsDeviceDimension}
+
+	return &plugin.Table{
+		Name:        "googlesearchconsole_search_analytics_by_device",
+		Description: "Lists the search traffic data (clicks, impressions, CTR and position) of a site per device type.",
+		List: &plugin.ListConfig{
+			KeyColumns: getSearchAnalyticsKeyColumns(dimensions),
+			Hydrate:    listSearchAnalyticsByDimension(searchAnalyticsDeviceDimension),
+		},
+		Columns: getSearchAnalyticsColumns(dimensions),
+	}
+}
@@ -0,0 +1,23 @@
+package googlesearchconsole
+
+import (
+	"context"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+)
+
+//// TABLE DEFINITION
+
+func tableGoogleSearchConsoleSearchAnalyticsByPage(_ context.Context) *plugin.Table {
+	dimensions := []searchAnalyticsDimension{searchAnalyticsPageDimension}
+
+	return &plugin.Table{
+		Name:        "googlesearchconsole_search_analytics_by_page",
+		Description: "Lists the search traffic data (clicks, impressions, CTR and position) of a site per page.",
+		List: &plugin.ListConfig{
+			KeyColumns: getSearchAnalyticsKeyColumns(dimensions),
+			Hydrate:    listSearchAnalyticsByDimension(searchAnalyticsPageDimension),
+		},
+		Columns: getSearchAnalyticsColumns(dimensions),
+	}
+}
@@ -0,0 +1,23 @@
+package googlesearchconsole
+
+import (
+	"context"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+)
+
+//// TABLE DEFINITION
+
+func tableGoogleSearchConsoleSearchAnalyticsByQuery(_ context.Context) *plugin.Table {
+	dimensions := []searchAnalyticsDimension{searchAnalyticsQueryDimension}
+
+	return &plugin.Table{
+		Name:        "googlesearchconsole_search_analytics_by_query",
+		Description: "Lists the search traffic data (clicks, impressions, CTR and position) of a site per search query.",
+		List: &plugin.ListConfig{
+			KeyColumns: getSearchAnalyticsKeyColumns(dimensions),
+			Hydrate:    listSearchAnalyticsByDimension(searchAnalyticsQueryDimension),
+		},
+		Columns: getSearchAnalyticsColumns(dimensions),
+	}
+}
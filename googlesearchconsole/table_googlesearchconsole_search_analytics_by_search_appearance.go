@@ -0,0 +1,118 @@
+package googlesearchconsole
+
+import (
+	"context"
+	"slices"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"google.golang.org/api/searchconsole/v1"
+)
+
+//// TABLE DEFINITION
+
+func tableGoogleSearchConsoleSearchAnalyticsBySearchAppearance(_ context.Context) *plugin.Table {
+	return &plugin.Table{
+		Name:        "googlesearchconsole_search_analytics_by_search_appearance",
+		Description: "Lists the search traffic data (clicks, impressions, CTR and position) of a site per search appearance (rich result type).",
+		List: &plugin.ListConfig{
+			KeyColumns: getSearchAnalyticsKeyColumns(searchAnalyticsDimensions),
+			Hydrate:    listSearchAnalyticsBySearchAppearance,
+		},
+		Columns: getSearchAnalyticsBySearchAppearanceColumns(),
+	}
+}
+
+func getSearchAnalyticsBySearchAppearanceColumns() []*plugin.Column {
+	columns := getSearchAnalyticsColumns(searchAnalyticsDimensions)
+	for _, column := range columns {
+		if column.Name == "search_appearance" {
+			column.Description = "The search result feature (rich result type) in which the page appeared, such as AMP_BLUE_LINK, VIDEO or REVIEW_SNIPPET."
+		}
+	}
+	return columns
+}
+
+//// LIST FUNCTION
+
+func listSearchAnalyticsBySearchAppearance(ctx context.Context, d *plugin.QueryData, _ *plugin.HydrateData) (interface{}, error) {
+	if d.EqualsQualString("site_url") == "" {
+		return nil, nil
+	}
+
+	appearanceReq, appearanceTemplate, err := buildSearchAnalyticsRequest(d, []string{"searchAppearance"}, "final", 28)
+	if err != nil {
+		plugin.Logger(ctx).Error("googlesearchconsole_search_analytics_by_search_appearance.listSearchAnalyticsBySearchAppearance", "validation_error", err)
+		return nil, err
+	}
+
+	// The searchAppearance dimension cannot be combined with other dimensions, so the other
+	// requested dimensions are fetched per search appearance, filtering on it instead
+	var dimensions []string
+	for _, dim := range searchAnalyticsDimensions {
+		if dim.Column == "search_appearance" {
+			continue
+		}
+		if isSearchAnalyticsDimensionSelected(d, dim) {
+			dimensions = append(dimensions, dim.Dimension)
+		}
+	}
+
+	if len(dimensions) == 0 {
+		err = streamSearchAnalytics(ctx, d, appearanceReq, appearanceTemplate)
+		if err != nil {
+			plugin.Logger(ctx).Error("googlesearchconsole_search_analytics_by_search_appearance.listSearchAnalyticsBySearchAppearance", "api_error", err)
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	req, template, err := buildSearchAnalyticsRequest(d, dimensions, "final", 28)
+	if err != nil {
+		plugin.Logger(ctx).Error("googlesearchconsole_search_analytics_by_search_appearance.listSearchAnalyticsBySearchAppearance", "validation_error", err)
+		return nil, err
+	}
+
+	var appearances []string
+	err = paginateSearchAnalytics(ctx, d, template.SiteUrl, appearanceReq, -1, func(_ *searchconsole.SearchAnalyticsQueryResponse, apiRow *searchconsole.ApiDataRow) bool {
+		if len(apiRow.Keys) > 0 {
+			appearances = append(appearances, apiRow.Keys[0])
+		}
+		return true
+	})
+	if err != nil {
+		plugin.Logger(ctx).Error("googlesearchconsole_search_analytics_by_search_appearance.listSearchAnalyticsBySearchAppearance", "api_error", err)
+		return nil, err
+	}
+
+	for _, appearance := range appearances {
+		appearanceFilter := &searchconsole.ApiDimensionFilterGroup{
+			GroupType: "and",
+			Filters: []*searchconsole.ApiDimensionFilter{
+				{
+					Dimension:  "searchAppearance",
+					Operator:   "equals",
+					Expression: appearance,
+				},
+			},
+		}
+
+		typeReq := *req
+		typeReq.DimensionFilterGroups = append(slices.Clone(req.DimensionFilterGroups), appearanceFilter)
+
+		typeTemplate := *template
+		typeTemplate.SearchAppearance = appearance
+
+		err = streamSearchAnalytics(ctx, d, &typeReq, &typeTemplate)
+		if err != nil {
+			plugin.Logger(ctx).Error("googlesearchconsole_search_analytics_by_search_appearance.listSearchAnalyticsBySearchAppearance", "api_error", err)
+			return nil, err
+		}
+
+		// Context can be cancelled due to manual cancellation or the limit has been hit
+		if d.RowsRemaining(ctx) == 0 {
+			return nil, nil
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,434 @@
+package googlesearchconsole
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+	"google.golang.org/api/searchconsole/v1"
+)
+
+//// TABLE DEFINITION
+
+func tableGoogleSearchConsoleSearchAnalyticsComparison(_ context.Context) *plugin.Table {
+	return &plugin.Table{
+		Name:        "googlesearchconsole_search_analytics_comparison",
+		Description: "Compares the search traffic data (clicks, impressions, CTR and position) of a site between two periods.",
+		List: &plugin.ListConfig{
+			KeyColumns: []*plugin.KeyColumn{
+				{
+					Name:    "site_url",
+					Require: plugin.Required,
+				},
+				{
+					Name:       "dimension",
+					Require:    plugin.Optional,
+					CacheMatch: "exact",
+				},
+				{
+					Name:       "current_start_date",
+					Require:    plugin.Optional,
+					CacheMatch: "exact",
+				},
+				{
+					Name:       "current_end_date",
+					Require:    plugin.Optional,
+					CacheMatch: "exact",
+				},
+				{
+					Name:       "previous_start_date",
+					Require:    plugin.Optional,
+					CacheMatch: "exact",
+				},
+				{
+					Name:       "previous_end_date",
+					Require:    plugin.Optional,
+					CacheMatch: "exact",
+				},
+				{
+					Name:       "search_type",
+					Require:    plugin.Optional,
+					CacheMatch: "exact",
+				},
+				{
+					Name:       "data_state",
+					Require:    plugin.Optional,
+					CacheMatch: "exact",
+				},
+				{
+					Name:       "aggregation_type",
+					Require:    plugin.Optional,
+					CacheMatch: "exact",
+				},
+			},
+			Hydrate: listSearchAnalyticsComparisons,
+		},
+		Columns: []*plugin.Column{
+			{
+				Name:        "site_url",
+				Description: "The URL of the site.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "dimension",
+				Description: "The dimension the periods are compared by (query, page, country, device or search_appearance). If not set, the totals of the site are compared.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "dimension_value",
+				Description: "The value of the dimension, e.g. the search query when comparing by query.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "current_start_date",
+				Description: "Start date of the current period, in PT (UTC - 8:00). Defaults to 28 days before current_end_date.",
+				Type:        proto.ColumnType_TIMESTAMP,
+			},
+			{
+				Name:        "current_end_date",
+				Description: "End date of the current period, in PT (UTC - 8:00). Defaults to the current date.",
+				Type:        proto.ColumnType_TIMESTAMP,
+			},
+			{
+				Name:        "previous_start_date",
+				Description: "Start date of the previous period, in PT (UTC - 8:00). Defaults to the start of a period of the same length as the current period, ending on previous_end_date.",
+				Type:        proto.ColumnType_TIMESTAMP,
+			},
+			{
+				Name:        "previous_end_date",
+				Description: "End date of the previous period, in PT (UTC - 8:00). Defaults to the day before current_start_date.",
+				Type:        proto.ColumnType_TIMESTAMP,
+			},
+			{
+				Name:        "search_type",
+				Description: "The search type (web, image, video, news, discover or googleNews). Default is web.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "data_state",
+				Description: "The data state (final, all or hourly_all). Default is final.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "aggregation_type",
+				Description: "The requested aggregation type (auto, byPage, byProperty or byNewsShowcasePanel). Default is auto.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "current_clicks",
+				Description: "The number of clicks in the current period.",
+				Type:        proto.ColumnType_INT,
+				Transform:   transform.FromField("CurrentClicks"),
+			},
+			{
+				Name:        "current_impressions",
+				Description: "The number of impressions in the current period.",
+				Type:        proto.ColumnType_INT,
+				Transform:   transform.FromField("CurrentImpressions"),
+			},
+			{
+				Name:        "current_ctr",
+				Description: "The click-through rate in the current period, between 0 and 1.",
+				Type:        proto.ColumnType_DOUBLE,
+				Transform:   transform.FromField("CurrentCtr"),
+			},
+			{
+				Name:        "current_position",
+				Description: "The average position in the current period. Null if there were no impressions.",
+				Type:        proto.ColumnType_DOUBLE,
+				Transform:   transform.FromField("CurrentPosition"),
+			},
+			{
+				Name:        "previous_clicks",
+				Description: "The number of clicks in the previous period.",
+				Type:        proto.ColumnType_INT,
+				Transform:   transform.FromField("PreviousClicks"),
+			},
+			{
+				Name:        "previous_impressions",
+				Description: "The number of impressions in the previous period.",
+				Type:        proto.ColumnType_INT,
+				Transform:   transform.FromField("PreviousImpressions"),
+			},
+			{
+				Name:        "previous_ctr",
+				Description: "The click-through rate in the previous period, between 0 and 1.",
+				Type:        proto.ColumnType_DOUBLE,
+				Transform:   transform.FromField("PreviousCtr"),
+			},
+			{
+				Name:        "previous_position",
+				Description: "The average position in the previous period. Null if there were no impressions.",
+				Type:        proto.ColumnType_DOUBLE,
+				Transform:   transform.FromField("PreviousPosition"),
+			},
+			{
+				Name:        "clicks_change",
+				Description: "The change in clicks from the previous to the current period.",
+				Type:        proto.ColumnType_INT,
+				Transform:   transform.FromField("ClicksChange"),
+			},
+			{
+				Name:        "clicks_change_percent",
+				Description: "The change in clicks from the previous to the current period, in percent. Null if there were no clicks in the previous period.",
+				Type:        proto.ColumnType_DOUBLE,
+				Transform:   transform.FromField("ClicksChangePercent"),
+			},
+			{
+				Name:        "impressions_change",
+				Description: "The change in impressions from the previous to the current period.",
+				Type:        proto.ColumnType_INT,
+				Transform:   transform.FromField("ImpressionsChange"),
+			},
+			{
+				Name:        "impressions_change_percent",
+				Description: "The change in impressions from the previous to the current period, in percent. Null if there were no impressions in the previous period.",
+				Type:        proto.ColumnType_DOUBLE,
+				Transform:   transform.FromField("ImpressionsChangePercent"),
+			},
+			{
+				Name:        "ctr_change",
+				Description: "The change in click-through rate from the previous to the current period.",
+				Type:        proto.ColumnType_DOUBLE,
+				Transform:   transform.FromField("CtrChange"),
+			},
+			{
+				Name:        "ctr_change_percent",
+				Description: "The change in click-through rate from the previous to the current period, in percent. Null if the previous click-through rate was 0.",
+				Type:        proto.ColumnType_DOUBLE,
+				Transform:   transform.FromField("CtrChangePercent"),
+			},
+			{
+				Name:        "position_change",
+				Description: "The change in average position from the previous to the current period. A negative value means the results moved up. Null if there were no impressions in one of the periods.",
+				Type:        proto.ColumnType_DOUBLE,
+				Transform:   transform.FromField("PositionChange"),
+			},
+			{
+				Name:        "position_change_percent",
+				Description: "The change in average position from the previous to the current period, in percent. Null if there were no impressions in one of the periods.",
+				Type:        proto.ColumnType_DOUBLE,
+				Transform:   transform.FromField("PositionChangePercent"),
+			},
+			{
+				Name:        "project",
+				Description: "The GCP Project associated with the credentials in use.",
+				Type:        proto.ColumnType_STRING,
+				Hydrate:     getProject,
+				Transform:   transform.FromValue(),
+			},
+		},
+	}
+}
+
+type SearchAnalyticsComparisonRow struct {
+	SiteUrl                  string
+	Dimension                string
+	DimensionValue           string
+	CurrentStartDate         time.Time
+	CurrentEndDate           time.Time
+	PreviousStartDate        time.Time
+	PreviousEndDate          time.Time
+	SearchType               string
+	DataState                string
+	AggregationType          string
+	CurrentClicks            float64
+	CurrentImpressions       float64
+	CurrentCtr               float64
+	CurrentPosition          *float64
+	PreviousClicks           float64
+	PreviousImpressions      float64
+	PreviousCtr              float64
+	PreviousPosition         *float64
+	ClicksChange             float64
+	ClicksChangePercent      *float64
+	ImpressionsChange        float64
+	ImpressionsChangePercent *float64
+	CtrChange                float64
+	CtrChangePercent         *float64
+	PositionChange           *float64
+	PositionChangePercent    *float64
+}
+
+//// LIST FUNCTION
+
+func listSearchAnalyticsComparisons(ctx context.Context, d *plugin.QueryData, _ *plugin.HydrateData) (interface{}, error) {
+	if d.EqualsQualString("site_url") == "" {
+		return nil, nil
+	}
+
+	dimension, err := getQualStringValue(d, "dimension")
+	if err != nil {
+		plugin.Logger(ctx).Error("googlesearchconsole_search_analytics_comparison.listSearchAnalyticsComparisons", "validation_error", err)
+		return nil, err
+	}
+
+	var dimensions []string
+	if dimension != "" {
+		var apiDimension string
+		for _, dim := range searchAnalyticsDimensions {
+			if dim.Filterable && dim.Column == dimension {
+				apiDimension = dim.Dimension
+			}
+		}
+		if apiDimension == "" {
+			err := fmt.Errorf("invalid dimension %q, the dimension should be one of 'query', 'page', 'country', 'device' or 'search_appearance'", dimension)
+			plugin.Logger(ctx).Error("googlesearchconsole_search_analytics_comparison.listSearchAnalyticsComparisons", "validation_error", err)
+			return nil, err
+		}
+		dimensions = []string{apiDimension}
+	}
+
+	currentStart, currentEnd, previousStart, previousEnd, err := getSearchAnalyticsComparisonDateRanges(d)
+	if err != nil {
+		plugin.Logger(ctx).Error("googlesearchconsole_search_analytics_comparison.listSearchAnalyticsComparisons", "validation_error", err)
+		return nil, err
+	}
+
+	req, template, err := buildSearchAnalyticsRequest(d, dimensions, "final", 28)
+	if err != nil {
+		plugin.Logger(ctx).Error("googlesearchconsole_search_analytics_comparison.listSearchAnalyticsComparisons", "validation_error", err)
+		return nil, err
+	}
+
+	currentReq := *req
+	currentReq.StartDate = currentStart.Format(searchAnalyticsDateFormat)
+	currentReq.EndDate = currentEnd.Format(searchAnalyticsDateFormat)
+	current, err := getSearchAnalyticsRowsByKey(ctx, d, template.SiteUrl, &currentReq)
+	if err != nil {
+		plugin.Logger(ctx).Error("googlesearchconsole_search_analytics_comparison.listSearchAnalyticsComparisons", "api_error", err)
+		return nil, err
+	}
+
+	previousReq := *req
+	previousReq.StartDate = previousStart.Format(searchAnalyticsDateFormat)
+	previousReq.EndDate = previousEnd.Format(searchAnalyticsDateFormat)
+	previous, err := getSearchAnalyticsRowsByKey(ctx, d, template.SiteUrl, &previousReq)
+	if err != nil {
+		plugin.Logger(ctx).Error("googlesearchconsole_search_analytics_comparison.listSearchAnalyticsComparisons", "api_error", err)
+		return nil, err
+	}
+
+	// Stream the values of the current period first, followed by the values only seen in the previous period
+	keys := current.Keys
+	for _, key := range previous.Keys {
+		if _, ok := current.Rows[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+
+	for _, key := range keys {
+		row := &SearchAnalyticsComparisonRow{
+			SiteUrl:           template.SiteUrl,
+			Dimension:         dimension,
+			DimensionValue:    key,
+			CurrentStartDate:  currentStart,
+			CurrentEndDate:    currentEnd,
+			PreviousStartDate: previousStart,
+			PreviousEndDate:   previousEnd,
+			SearchType:        template.SearchType,
+			DataState:         template.DataState,
+			AggregationType:   template.AggregationType,
+		}
+
+		if c := current.Rows[key]; c != nil {
+			row.CurrentClicks = c.Clicks
+			row.CurrentImpressions = c.Impressions
+			row.CurrentCtr = c.Ctr
+			row.CurrentPosition = &c.Position
+		}
+		if p := previous.Rows[key]; p != nil {
+			row.PreviousClicks = p.Clicks
+			row.PreviousImpressions = p.Impressions
+			row.PreviousCtr = p.Ctr
+			row.PreviousPosition = &p.Position
+		}
+
+		row.ClicksChange = row.CurrentClicks - row.PreviousClicks
+		row.ClicksChangePercent = percentChange(row.CurrentClicks, row.PreviousClicks)
+		row.ImpressionsChange = row.CurrentImpressions - row.PreviousImpressions
+		row.ImpressionsChangePercent = percentChange(row.CurrentImpressions, row.PreviousImpressions)
+		row.CtrChange = row.CurrentCtr - row.PreviousCtr
+		row.CtrChangePercent = percentChange(row.CurrentCtr, row.PreviousCtr)
+		if row.CurrentPosition != nil && row.PreviousPosition != nil {
+			positionChange := *row.CurrentPosition - *row.PreviousPosition
+			row.PositionChange = &positionChange
+			row.PositionChangePercent = percentChange(*row.CurrentPosition, *row.PreviousPosition)
+		}
+
+		d.StreamListItem(ctx, row)
+
+		// Context can be cancelled due to manual cancellation or the limit has been hit
+		if d.RowsRemaining(ctx) == 0 {
+			return nil, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// searchAnalyticsRowsByKey holds API rows by their joined keys, along with the keys in the order
+// they were returned.
+type searchAnalyticsRowsByKey struct {
+	Keys []string
+	Rows map[string]*searchconsole.ApiDataRow
+}
+
+// getSearchAnalyticsRowsByKey fetches all the rows of the request and indexes them by their keys.
+func getSearchAnalyticsRowsByKey(ctx context.Context, d *plugin.QueryData, siteUrl string, req *searchconsole.SearchAnalyticsQueryRequest) (*searchAnalyticsRowsByKey, error) {
+	result := &searchAnalyticsRowsByKey{Rows: map[string]*searchconsole.ApiDataRow{}}
+	err := paginateSearchAnalytics(ctx, d, siteUrl, req, -1, func(_ *searchconsole.SearchAnalyticsQueryResponse, apiRow *searchconsole.ApiDataRow) bool {
+		key := strings.Join(apiRow.Keys, "\x00")
+		result.Keys = append(result.Keys, key)
+		result.Rows[key] = apiRow
+		return true
+	})
+	return result, err
+}
+
+// getSearchAnalyticsComparisonDateRanges returns the current and previous periods from the quals.
+// The current period defaults to the last 28 days, and the previous period to the period of the
+// same length that ends the day before the current period.
+func getSearchAnalyticsComparisonDateRanges(d *plugin.QueryData) (time.Time, time.Time, time.Time, time.Time, error) {
+	currentEnd := time.Now().UTC().Truncate(24 * time.Hour)
+	if d.EqualsQuals["current_end_date"] != nil {
+		currentEnd = d.EqualsQuals["current_end_date"].GetTimestampValue().AsTime()
+	}
+
+	currentStart := currentEnd.AddDate(0, 0, -28)
+	if d.EqualsQuals["current_start_date"] != nil {
+		currentStart = d.EqualsQuals["current_start_date"].GetTimestampValue().AsTime()
+	}
+
+	previousEnd := currentStart.AddDate(0, 0, -1)
+	if d.EqualsQuals["previous_end_date"] != nil {
+		previousEnd = d.EqualsQuals["previous_end_date"].GetTimestampValue().AsTime()
+	}
+
+	previousStart := previousEnd.Add(-currentEnd.Sub(currentStart))
+	if d.EqualsQuals["previous_start_date"] != nil {
+		previousStart = d.EqualsQuals["previous_start_date"].GetTimestampValue().AsTime()
+	}
+
+	if currentStart.After(currentEnd) {
+		return currentStart, currentEnd, previousStart, previousEnd, fmt.Errorf("current_start_date %s must not be after current_end_date %s", currentStart.Format(searchAnalyticsDateFormat), currentEnd.Format(searchAnalyticsDateFormat))
+	}
+	if previousStart.After(previousEnd) {
+		return currentStart, currentEnd, previousStart, previousEnd, fmt.Errorf("previous_start_date %s must not be after previous_end_date %s", previousStart.Format(searchAnalyticsDateFormat), previousEnd.Format(searchAnalyticsDateFormat))
+	}
+
+	return currentStart, currentEnd, previousStart, previousEnd, nil
+}
+
+// percentChange returns the change from previous to current in percent, or nil if previous is 0.
+func percentChange(current float64, previous float64) *float64 {
+	if previous == 0 {
+		return nil
+	}
+	change := (current - previous) / previous * 100
+	return &change
+}
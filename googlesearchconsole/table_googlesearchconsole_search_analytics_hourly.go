@@ -0,0 +1,73 @@
+package googlesearchconsole
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+)
+
+//// TABLE DEFINITION
+
+func tableGoogleSearchConsoleSearchAnalyticsHourly(_ context.Context) *plugin.Table {
+	return &plugin.Table{
+		Name:        "googlesearchconsole_search_analytics_hourly",
+		Description: "Lists the hourly search traffic data (clicks, impressions, CTR and position) of a site.",
+		List: &plugin.ListConfig{
+			KeyColumns: getSearchAnalyticsKeyColumns(searchAnalyticsDimensions),
+			Hydrate:    listSearchAnalyticsHourly,
+		},
+		Columns: getSearchAnalyticsHourlyColumns(),
+	}
+}
+
+// getSearchAnalyticsHourlyColumns returns the search analytics columns, with the date
+// column replaced by the hour column.
+func getSearchAnalyticsHourlyColumns() []*plugin.Column {
+	var columns []*plugin.Column
+	for _, column := range getSearchAnalyticsColumns(searchAnalyticsDimensions) {
+		switch column.Name {
+		case "date":
+			column = &plugin.Column{
+				Name:        "hour",
+				Description: "The hour in which the search was made, in the timezone of the property.",
+				Type:        proto.ColumnType_TIMESTAMP,
+			}
+		case "start_date":
+			column.Description = "Start date of the requested date range, in PT (UTC - 8:00). Defaults to 3 days before end_date."
+		case "data_state":
+			column.Description = "The data state. Hourly data is only available with the hourly_all data state, which includes fresh data that may still change."
+		}
+		columns = append(columns, column)
+	}
+	return columns
+}
+
+//// LIST FUNCTION
+
+func listSearchAnalyticsHourly(ctx context.Context, d *plugin.QueryData, _ *plugin.HydrateData) (interface{}, error) {
+	if d.EqualsQualString("site_url") == "" {
+		return nil, nil
+	}
+
+	dimensions := append([]string{"hour"}, getSearchAnalyticsRequestedDimensions(d)...)
+	req, template, err := buildSearchAnalyticsRequest(d, dimensions, "hourly_all", 3)
+	if err != nil {
+		plugin.Logger(ctx).Error("googlesearchconsole_search_analytics_hourly.listSearchAnalyticsHourly", "validation_error", err)
+		return nil, err
+	}
+	if req.DataState != "hourly_all" {
+		err = fmt.Errorf("invalid data_state %q, hourly data is only available with the 'hourly_all' data_state", req.DataState)
+		plugin.Logger(ctx).Error("googlesearchconsole_search_analytics_hourly.listSearchAnalyticsHourly", "validation_error", err)
+		return nil, err
+	}
+
+	err = streamSearchAnalytics(ctx, d, req, template)
+	if err != nil {
+		plugin.Logger(ctx).Error("googlesearchconsole_search_analytics_hourly.listSearchAnalyticsHourly", "api_error", err)
+		return nil, err
+	}
+
+	return nil, nil
+}
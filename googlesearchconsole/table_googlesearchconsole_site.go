@@ -48,33 +48,14 @@ func tableGoogleSearchConsoleSite(_ context.Context) *plugin.Table {
 //// LIST FUNCTION
 
 func listSites(ctx context.Context, d *plugin.QueryData, _ *plugin.HydrateData) (interface{}, error) {
-
-	// Create client
-	opts, err := getSearchConsoleSessionConfig(ctx, d)
-	if err != nil {
-		plugin.Logger(ctx).Error("googlesearchconsole_site.listSites", "connection_error", err)
-		return nil, err
-	}
-
-	// Create service
-	svc, err := searchconsole.NewService(ctx, opts...)
-	if err != nil {
-		plugin.Logger(ctx).Error("googlesearchconsole_site.listSites", "service_creation_error", err)
-		return nil, err
-	}
-
-	req := svc.Sites.List()
-
-	resp, err := req.Context(ctx).Do()
+	sites, err := getSitesService(ctx, d)
 	if err != nil {
 		plugin.Logger(ctx).Error("googlesearchconsole_site.listSites", "api_error", err)
 		return nil, err
 	}
 
-	if resp.SiteEntry != nil {
-		for _, site := range resp.SiteEntry {
-			d.StreamListItem(ctx, site)
-		}
+	for _, site := range sites {
+		d.StreamListItem(ctx, site)
 	}
 
 	return nil, nil
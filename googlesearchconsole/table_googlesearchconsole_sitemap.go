@@ -112,33 +112,15 @@ func listSitemaps(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateDat
 		siteUrl = site.SiteUrl
 	}
 
-	// Create client
-	opts, err := getSearchConsoleSessionConfig(ctx, d)
-	if err != nil {
-		plugin.Logger(ctx).Error("googlesearchconsole_sitemap.listSitemaps", "connection_error", err)
-		return nil, err
-	}
-
-	// Create service
-	svc, err := searchconsole.NewService(ctx, opts...)
-	if err != nil {
-		plugin.Logger(ctx).Error("googlesearchconsole_sitemap.listSitemaps", "service_creation_error", err)
-		return nil, err
-	}
-
-	req := svc.Sitemaps.List(siteUrl)
-
-	resp, err := req.Context(ctx).Do()
+	sitemaps, err := getSitemapsService(ctx, d, siteUrl)
 	if err != nil {
 		plugin.Logger(ctx).Error("googlesearchconsole_sitemap.listSitemaps", "api_error", err)
 		return nil, err
 	}
 
-	if resp.Sitemap != nil {
-		for _, sitemap := range resp.Sitemap {
-			info := &SitemapInfo{SiteUrl: siteUrl, WmxSitemap: sitemap}
-			d.StreamListItem(ctx, info)
-		}
+	for _, sitemap := range sitemaps {
+		info := &SitemapInfo{SiteUrl: siteUrl, WmxSitemap: sitemap}
+		d.StreamListItem(ctx, info)
 	}
 
 	return nil, nil
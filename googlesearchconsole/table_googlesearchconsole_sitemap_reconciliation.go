@@ -0,0 +1,178 @@
+package googlesearchconsole
+
+import (
+	"context"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+	sitemapper "github.com/yterajima/go-sitemap"
+)
+
+//// TABLE DEFINITION
+
+func tableGoogleSearchConsoleSitemapReconciliation(_ context.Context) *plugin.Table {
+	return &plugin.Table{
+		Name:        "googlesearchconsole_sitemap_reconciliation",
+		Description: "Lists the URLs of the submitted sitemaps of a site together with the pages receiving impressions, showing whether each URL is listed in a sitemap and appears in search results.",
+		List: &plugin.ListConfig{
+			KeyColumns: append(getSearchAnalyticsKeyColumns(nil), &plugin.KeyColumn{
+				Name:       "sitemap_url",
+				Require:    plugin.Optional,
+				CacheMatch: "exact",
+			}),
+			Hydrate: listSitemapReconciliations,
+		},
+		Columns: getSitemapReconciliationColumns(),
+	}
+}
+
+// getSitemapReconciliationColumns returns the search analytics columns without dimensions,
+// with the URL columns inserted before the metrics.
+func getSitemapReconciliationColumns() []*plugin.Column {
+	var columns []*plugin.Column
+	for _, column := range getSearchAnalyticsColumns(nil) {
+		switch column.Name {
+		case "clicks":
+			columns = append(columns, []*plugin.Column{
+				{
+					Name:        "url",
+					Description: "The URL of the page.",
+					Type:        proto.ColumnType_STRING,
+				},
+				{
+					Name:        "page_group",
+					Description: "The name of the first page group of the connection config whose regular expression matches the URL of the page.",
+					Type:        proto.ColumnType_STRING,
+				},
+				{
+					Name:        "sitemap_url",
+					Description: "The URL of the sitemap the pages are compared with. If not set, the URLs of all the submitted sitemaps of the site are compared, and the first sitemap listing the page is reported.",
+					Type:        proto.ColumnType_STRING,
+				},
+				{
+					Name:        "in_sitemap",
+					Description: "True if the page is listed in a sitemap.",
+					Type:        proto.ColumnType_BOOL,
+					Transform:   transform.FromField("InSitemap"),
+				},
+				{
+					Name:        "has_impressions",
+					Description: "True if the page received impressions in the date range.",
+					Type:        proto.ColumnType_BOOL,
+					Transform:   transform.FromField("HasImpressions"),
+				},
+			}...)
+		case "position":
+			column.Description = "The average position in the search results. Null if the page received no impressions."
+			column.Transform = transform.FromField("Position").NullIfZero()
+		}
+		columns = append(columns, column)
+	}
+	return columns
+}
+
+type SitemapReconciliationRow struct {
+	SearchAnalyticsRow
+	Url            string
+	SitemapUrl     string
+	InSitemap      bool
+	HasImpressions bool
+}
+
+//// LIST FUNCTION
+
+func listSitemapReconciliations(ctx context.Context, d *plugin.QueryData, _ *plugin.HydrateData) (interface{}, error) {
+	if d.EqualsQualString("site_url") == "" {
+		return nil, nil
+	}
+
+	req, template, err := buildSearchAnalyticsRequest(d, []string{"page"}, "final", 28)
+	if err != nil {
+		plugin.Logger(ctx).Error("googlesearchconsole_sitemap_reconciliation.listSitemapReconciliations", "validation_error", err)
+		return nil, err
+	}
+
+	// sitemap_url is optional and defaults to all submitted sitemaps, but only a single value is
+	// supported, as the pages missing from the sitemap are reported with its URL
+	smUrl, err := getQualStringValue(d, "sitemap_url")
+	if err != nil {
+		plugin.Logger(ctx).Error("googlesearchconsole_sitemap_reconciliation.listSitemapReconciliations", "validation_error", err)
+		return nil, err
+	}
+
+	var sitemapUrls []string
+	if smUrl != "" {
+		sitemapUrls = []string{smUrl}
+	} else {
+		sitemaps, err := getSitemapsService(ctx, d, template.SiteUrl)
+		if err != nil {
+			plugin.Logger(ctx).Error("googlesearchconsole_sitemap_reconciliation.listSitemapReconciliations", "api_error", err)
+			return nil, err
+		}
+		for _, sitemap := range sitemaps {
+			sitemapUrls = append(sitemapUrls, sitemap.Path)
+		}
+	}
+
+	// Sitemap indexes are expanded by the sitemap parser, and each URL is reported with the
+	// first sitemap it was found in
+	var urls []string
+	sitemapPerUrl := map[string]string{}
+	for _, sitemapUrl := range sitemapUrls {
+		sitemap, err := sitemapper.Get(sitemapUrl, nil)
+		if err != nil {
+			plugin.Logger(ctx).Error("googlesearchconsole_sitemap_reconciliation.listSitemapReconciliations", "sitemap_error", err)
+			return nil, err
+		}
+		for _, sitemapURL := range sitemap.URL {
+			if _, ok := sitemapPerUrl[sitemapURL.Loc]; !ok {
+				urls = append(urls, sitemapURL.Loc)
+				sitemapPerUrl[sitemapURL.Loc] = sitemapUrl
+			}
+		}
+	}
+
+	rows, err := getAllSearchAnalyticsRows(ctx, d, req, template)
+	if err != nil {
+		plugin.Logger(ctx).Error("googlesearchconsole_sitemap_reconciliation.listSitemapReconciliations", "api_error", err)
+		return nil, err
+	}
+
+	rowPerPage := map[string]*SearchAnalyticsRow{}
+	for _, row := range rows {
+		rowPerPage[row.Page] = row
+		if _, ok := sitemapPerUrl[row.Page]; !ok {
+			urls = append(urls, row.Page)
+		}
+	}
+
+	for _, url := range urls {
+		result := &SitemapReconciliationRow{
+			SearchAnalyticsRow: *template,
+			Url:                url,
+		}
+		if row, ok := rowPerPage[url]; ok {
+			result.SearchAnalyticsRow = *row
+			result.HasImpressions = row.Impressions > 0
+		}
+		if sitemapUrl, ok := sitemapPerUrl[url]; ok {
+			result.SitemapUrl = sitemapUrl
+			result.InSitemap = true
+		}
+		// The pages missing from a requested sitemap are reported with it, so that
+		// they match the sitemap_url qual
+		if smUrl != "" {
+			result.SitemapUrl = smUrl
+		}
+		result.PageGroup = getPageGroupName(template.pageGroups, url)
+		d.StreamListItem(ctx, result)
+
+		// Context can be cancelled due to manual cancellation or the limit has been hit
+		if d.RowsRemaining(ctx) == 0 {
+			return nil, nil
+		}
+	}
+
+	return nil, nil
+}
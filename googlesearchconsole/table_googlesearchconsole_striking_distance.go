@@ -0,0 +1,177 @@
+package googlesearchconsole
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+const (
+	strikingDistanceDefaultMinImpressions = 100
+	strikingDistanceDefaultMinPosition    = 8
+	strikingDistanceDefaultMaxPosition    = 20
+	strikingDistanceTargetPosition        = 3
+)
+
+//// TABLE DEFINITION
+
+func tableGoogleSearchConsoleStrikingDistance(_ context.Context) *plugin.Table {
+	dimensions := []searchAnalyticsDimension{searchAnalyticsQueryDimension, searchAnalyticsPageDimension}
+	return &plugin.Table{
+		Name:        "googlesearchconsole_striking_distance",
+		Description: "Lists the query and page pairs of a site ranking just outside the top positions, with an estimate of the clicks they would gain by moving up to position 3.",
+		List: &plugin.ListConfig{
+			KeyColumns: append(getSearchAnalyticsKeyColumns(dimensions), []*plugin.KeyColumn{
+				{
+					Name:       "min_impressions",
+					Require:    plugin.Optional,
+					CacheMatch: "exact",
+				},
+				{
+					Name:       "min_position",
+					Require:    plugin.Optional,
+					CacheMatch: "exact",
+				},
+				{
+					Name:       "max_position",
+					Require:    plugin.Optional,
+					CacheMatch: "exact",
+				},
+			}...),
+			Hydrate: listStrikingDistances,
+		},
+		Columns: append(getSearchAnalyticsColumns(dimensions), []*plugin.Column{
+			{
+				Name:        "min_impressions",
+				Description: "The minimum number of impressions of the returned query and page pairs. Default is 100.",
+				Type:        proto.ColumnType_INT,
+				Transform:   transform.FromField("MinImpressions"),
+			},
+			{
+				Name:        "min_position",
+				Description: "The minimum average position of the returned query and page pairs. Default is 8.",
+				Type:        proto.ColumnType_DOUBLE,
+				Transform:   transform.FromField("MinPosition"),
+			},
+			{
+				Name:        "max_position",
+				Description: "The maximum average position of the returned query and page pairs. Default is 20.",
+				Type:        proto.ColumnType_DOUBLE,
+				Transform:   transform.FromField("MaxPosition"),
+			},
+			{
+				Name:        "target_position",
+				Description: "The position the estimate is made for.",
+				Type:        proto.ColumnType_INT,
+			},
+			{
+				Name:        "target_ctr",
+				Description: "The click-through rate of the site at the target position, computed from all the query and page pairs of the site in the date range. Null if the site has no impressions at the target position.",
+				Type:        proto.ColumnType_DOUBLE,
+				Transform:   transform.FromField("TargetCtr"),
+			},
+			{
+				Name:        "estimated_clicks",
+				Description: "The estimated number of clicks at the target position, based on the impressions and the target click-through rate.",
+				Type:        proto.ColumnType_DOUBLE,
+				Transform:   transform.FromField("EstimatedClicks"),
+			},
+			{
+				Name:        "estimated_click_uplift",
+				Description: "The estimated number of additional clicks at the target position, compared to the actual clicks. Never negative.",
+				Type:        proto.ColumnType_DOUBLE,
+				Transform:   transform.FromField("EstimatedClickUplift"),
+			},
+		}...),
+	}
+}
+
+type StrikingDistanceRow struct {
+	SearchAnalyticsRow
+	MinImpressions       int64
+	MinPosition          float64
+	MaxPosition          float64
+	TargetPosition       int
+	TargetCtr            *float64
+	EstimatedClicks      *float64
+	EstimatedClickUplift *float64
+}
+
+//// LIST FUNCTION
+
+func listStrikingDistances(ctx context.Context, d *plugin.QueryData, _ *plugin.HydrateData) (interface{}, error) {
+	if d.EqualsQualString("site_url") == "" {
+		return nil, nil
+	}
+
+	minImpressions := int64(strikingDistanceDefaultMinImpressions)
+	if d.EqualsQuals["min_impressions"] != nil {
+		minImpressions = d.EqualsQuals["min_impressions"].GetInt64Value()
+	}
+	minPosition := float64(strikingDistanceDefaultMinPosition)
+	if d.EqualsQuals["min_position"] != nil {
+		minPosition = d.EqualsQuals["min_position"].GetDoubleValue()
+	}
+	maxPosition := float64(strikingDistanceDefaultMaxPosition)
+	if d.EqualsQuals["max_position"] != nil {
+		maxPosition = d.EqualsQuals["max_position"].GetDoubleValue()
+	}
+	if minPosition > maxPosition {
+		err := fmt.Errorf("min_position %g must not be greater than max_position %g", minPosition, maxPosition)
+		plugin.Logger(ctx).Error("googlesearchconsole_striking_distance.listStrikingDistances", "validation_error", err)
+		return nil, err
+	}
+
+	req, template, err := buildSearchAnalyticsRequest(d, []string{"query", "page"}, "final", 28)
+	if err != nil {
+		plugin.Logger(ctx).Error("googlesearchconsole_striking_distance.listStrikingDistances", "validation_error", err)
+		return nil, err
+	}
+
+	// The CTR curve is computed from all the rows, so they are fetched before streaming
+	rows, err := getAllSearchAnalyticsRows(ctx, d, req, template)
+	if err != nil {
+		plugin.Logger(ctx).Error("googlesearchconsole_striking_distance.listStrikingDistances", "api_error", err)
+		return nil, err
+	}
+
+	curve, err := getSiteSearchAnalyticsCtrCurve(ctx, d, req, template, rows)
+	if err != nil {
+		plugin.Logger(ctx).Error("googlesearchconsole_striking_distance.listStrikingDistances", "api_error", err)
+		return nil, err
+	}
+	targetCtr := curve.Ctr(strikingDistanceTargetPosition)
+
+	for _, row := range rows {
+		if row.Impressions < float64(minImpressions) || row.Position < minPosition || row.Position > maxPosition {
+			continue
+		}
+
+		result := &StrikingDistanceRow{
+			SearchAnalyticsRow: *row,
+			MinImpressions:     minImpressions,
+			MinPosition:        minPosition,
+			MaxPosition:        maxPosition,
+			TargetPosition:     strikingDistanceTargetPosition,
+			TargetCtr:          targetCtr,
+		}
+		if targetCtr != nil {
+			estimatedClicks := row.Impressions * *targetCtr
+			uplift := math.Max(0, estimatedClicks-row.Clicks)
+			result.EstimatedClicks = &estimatedClicks
+			result.EstimatedClickUplift = &uplift
+		}
+		d.StreamListItem(ctx, result)
+
+		// Context can be cancelled due to manual cancellation or the limit has been hit
+		if d.RowsRemaining(ctx) == 0 {
+			return nil, nil
+		}
+	}
+
+	return nil, nil
+}
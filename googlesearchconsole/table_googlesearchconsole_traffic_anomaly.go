@@ -0,0 +1,441 @@
+package googlesearchconsole
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"time"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+	"google.golang.org/api/searchconsole/v1"
+)
+
+const (
+	trafficAnomalyDefaultLookbackDays     = 90
+	trafficAnomalyDefaultBaselineDays     = 28
+	trafficAnomalyDefaultZScoreThreshold  = 3
+	trafficAnomalyDefaultPercentThreshold = 50
+)
+
+//// TABLE DEFINITION
+
+func tableGoogleSearchConsoleTrafficAnomaly(_ context.Context) *plugin.Table {
+	return &plugin.Table{
+		Name:        "googlesearchconsole_traffic_anomaly",
+		Description: "Lists the daily clicks and impressions of a site compared to a rolling baseline, flagging the days that deviate from it.",
+		List: &plugin.ListConfig{
+			KeyColumns: []*plugin.KeyColumn{
+				{
+					Name:    "site_url",
+					Require: plugin.Required,
+				},
+				{
+					Name:       "page_prefix",
+					Require:    plugin.Optional,
+					CacheMatch: "exact",
+				},
+				{
+					Name:       "country",
+					Require:    plugin.Optional,
+					CacheMatch: "exact",
+				},
+				{
+					Name:       "search_type",
+					Require:    plugin.Optional,
+					CacheMatch: "exact",
+				},
+				{
+					Name:       "data_state",
+					Require:    plugin.Optional,
+					CacheMatch: "exact",
+				},
+				{
+					Name:       "lookback_days",
+					Require:    plugin.Optional,
+					CacheMatch: "exact",
+				},
+				{
+					Name:       "baseline_days",
+					Require:    plugin.Optional,
+					CacheMatch: "exact",
+				},
+				{
+					Name:       "method",
+					Require:    plugin.Optional,
+					CacheMatch: "exact",
+				},
+				{
+					Name:       "threshold",
+					Require:    plugin.Optional,
+					CacheMatch: "exact",
+				},
+			},
+			Hydrate: listTrafficAnomalies,
+		},
+		Columns: []*plugin.Column{
+			{
+				Name:        "site_url",
+				Description: "The URL of the site.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "page_prefix",
+				Description: "If set, only the traffic of the pages whose URL starts with the prefix is included.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "country",
+				Description: "If set, only the traffic from the country is included. The country is a lowercase ISO 3166-1 alpha-3 code, or an ISO 3166-1 alpha-2 code.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "search_type",
+				Description: "The search type (web, image, video, news, discover or googleNews). Default is web.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "data_state",
+				Description: "The data state (final or all). Default is final.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "lookback_days",
+				Description: "The number of days to report, ending on the latest day with data. Default is 90.",
+				Type:        proto.ColumnType_INT,
+			},
+			{
+				Name:        "baseline_days",
+				Description: "The number of days before each day the baseline is computed from. Default is 28.",
+				Type:        proto.ColumnType_INT,
+			},
+			{
+				Name:        "method",
+				Description: "How the deviation of a day is compared to the threshold, z_score or percent. Default is z_score.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "threshold",
+				Description: "The absolute z-score or percentage from which a day is an anomaly. Default is 3 for the z_score method and 50 for the percent method.",
+				Type:        proto.ColumnType_DOUBLE,
+				Transform:   transform.FromField("Threshold"),
+			},
+			{
+				Name:        "date",
+				Description: "The day, in PT (UTC - 8:00).",
+				Type:        proto.ColumnType_TIMESTAMP,
+			},
+			{
+				Name:        "metric",
+				Description: "The metric, clicks or impressions.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "value",
+				Description: "The value of the metric on the day.",
+				Type:        proto.ColumnType_INT,
+				Transform:   transform.FromField("Value"),
+			},
+			{
+				Name:        "baseline",
+				Description: "The average value of the metric over the baseline days before the day.",
+				Type:        proto.ColumnType_DOUBLE,
+				Transform:   transform.FromField("Baseline"),
+			},
+			{
+				Name:        "baseline_stddev",
+				Description: "The standard deviation of the metric over the baseline days before the day.",
+				Type:        proto.ColumnType_DOUBLE,
+				Transform:   transform.FromField("BaselineStddev"),
+			},
+			{
+				Name:        "deviation",
+				Description: "The difference between the value and the baseline.",
+				Type:        proto.ColumnType_DOUBLE,
+				Transform:   transform.FromField("Deviation"),
+			},
+			{
+				Name:        "deviation_percent",
+				Description: "The difference between the value and the baseline, in percent of the baseline. Null if the baseline is 0.",
+				Type:        proto.ColumnType_DOUBLE,
+				Transform:   transform.FromField("DeviationPercent"),
+			},
+			{
+				Name:        "z_score",
+				Description: "The number of standard deviations between the value and the baseline. Null if the standard deviation is 0.",
+				Type:        proto.ColumnType_DOUBLE,
+				Transform:   transform.FromField("ZScore"),
+			},
+			{
+				Name:        "direction",
+				Description: "Whether the value is above (up) or below (down) the baseline. Null if it equals the baseline.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "is_anomaly",
+				Description: "True if the z-score or the percentage of deviation, depending on the method, reaches the threshold. With the z_score method, any deviation from a baseline with a standard deviation of 0 is an anomaly.",
+				Type:        proto.ColumnType_BOOL,
+				Transform:   transform.FromField("IsAnomaly"),
+			},
+			{
+				Name:        "project",
+				Description: "The GCP Project associated with the credentials in use.",
+				Type:        proto.ColumnType_STRING,
+				Hydrate:     getProject,
+				Transform:   transform.FromValue(),
+			},
+		},
+	}
+}
+
+type TrafficAnomalyRow struct {
+	SiteUrl          string
+	PagePrefix       string
+	Country          string
+	SearchType       string
+	DataState        string
+	LookbackDays     int64
+	BaselineDays     int64
+	Method           string
+	Threshold        float64
+	Date             time.Time
+	Metric           string
+	Value            float64
+	Baseline         float64
+	BaselineStddev   float64
+	Deviation        float64
+	DeviationPercent *float64
+	ZScore           *float64
+	Direction        string
+	IsAnomaly        bool
+}
+
+//// LIST FUNCTION
+
+func listTrafficAnomalies(ctx context.Context, d *plugin.QueryData, _ *plugin.HydrateData) (interface{}, error) {
+	if d.EqualsQualString("site_url") == "" {
+		return nil, nil
+	}
+
+	template, err := getTrafficAnomalyTemplate(d)
+	if err != nil {
+		plugin.Logger(ctx).Error("googlesearchconsole_traffic_anomaly.listTrafficAnomalies", "validation_error", err)
+		return nil, err
+	}
+
+	endDate := time.Now().UTC().Truncate(24 * time.Hour)
+	req := &searchconsole.SearchAnalyticsQueryRequest{
+		StartDate:  endDate.AddDate(0, 0, -int(template.LookbackDays+template.BaselineDays)).Format(searchAnalyticsDateFormat),
+		EndDate:    endDate.Format(searchAnalyticsDateFormat),
+		Type:       template.SearchType,
+		DataState:  template.DataState,
+		Dimensions: []string{"date"},
+	}
+
+	var filters []*searchconsole.ApiDimensionFilter
+	if template.PagePrefix != "" {
+		filters = append(filters, &searchconsole.ApiDimensionFilter{
+			Dimension:  "page",
+			Operator:   "includingRegex",
+			Expression: "^" + regexp.QuoteMeta(template.PagePrefix),
+		})
+	}
+	if template.Country != "" {
+		country := template.Country
+		if alpha3, ok := getCountryAlpha3(country); ok {
+			country = alpha3
+		}
+		filters = append(filters, &searchconsole.ApiDimensionFilter{
+			Dimension:  "country",
+			Operator:   "equals",
+			Expression: country,
+		})
+	}
+	if len(filters) > 0 {
+		req.DimensionFilterGroups = []*searchconsole.ApiDimensionFilterGroup{{GroupType: "and", Filters: filters}}
+	}
+
+	clicksPerDay := map[time.Time]float64{}
+	impressionsPerDay := map[time.Time]float64{}
+	var firstDate, lastDate time.Time
+	err = paginateSearchAnalytics(ctx, d, template.SiteUrl, req, -1, func(_ *searchconsole.SearchAnalyticsQueryResponse, apiRow *searchconsole.ApiDataRow) bool {
+		if len(apiRow.Keys) == 0 {
+			return true
+		}
+		date, err := time.Parse(searchAnalyticsDateFormat, apiRow.Keys[0])
+		if err != nil {
+			return true
+		}
+		clicksPerDay[date] = apiRow.Clicks
+		impressionsPerDay[date] = apiRow.Impressions
+		if firstDate.IsZero() || date.Before(firstDate) {
+			firstDate = date
+		}
+		if date.After(lastDate) {
+			lastDate = date
+		}
+		return true
+	})
+	if err != nil {
+		plugin.Logger(ctx).Error("googlesearchconsole_traffic_anomaly.listTrafficAnomalies", "api_error", err)
+		return nil, err
+	}
+	if firstDate.IsZero() {
+		return nil, nil
+	}
+
+	// The days without any impression are not returned by the API, and count as 0. The days after
+	// the latest day with data are ignored, as they are usually not available yet.
+	var days []time.Time
+	for date := firstDate; !date.After(lastDate); date = date.AddDate(0, 0, 1) {
+		days = append(days, date)
+	}
+
+	for _, metric := range []string{"clicks", "impressions"} {
+		valuesPerDay := clicksPerDay
+		if metric == "impressions" {
+			valuesPerDay = impressionsPerDay
+		}
+
+		for i, date := range days {
+			if i < int(template.BaselineDays) || date.Before(lastDate.AddDate(0, 0, -int(template.LookbackDays)+1)) {
+				continue
+			}
+
+			var baselineValues []float64
+			for _, baselineDate := range days[i-int(template.BaselineDays) : i] {
+				baselineValues = append(baselineValues, valuesPerDay[baselineDate])
+			}
+
+			row := *template
+			row.Date = date
+			row.Metric = metric
+			row.Value = valuesPerDay[date]
+			setTrafficAnomalyDeviation(&row, baselineValues)
+			d.StreamListItem(ctx, &row)
+
+			// Context can be cancelled due to manual cancellation or the limit has been hit
+			if d.RowsRemaining(ctx) == 0 {
+				return nil, nil
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// getTrafficAnomalyTemplate validates the quals of the table, and returns a row holding them.
+func getTrafficAnomalyTemplate(d *plugin.QueryData) (*TrafficAnomalyRow, error) {
+	searchType, err := getSearchAnalyticsSearchType(d)
+	if err != nil {
+		return nil, err
+	}
+
+	dataState, err := getSearchAnalyticsDataState(d, "final")
+	if err != nil {
+		return nil, err
+	}
+	if dataState == "hourly_all" {
+		return nil, fmt.Errorf("invalid data_state %q, the data_state should be 'final' or 'all'", dataState)
+	}
+
+	pagePrefix, err := getQualStringValue(d, "page_prefix")
+	if err != nil {
+		return nil, err
+	}
+
+	country, err := getQualStringValue(d, "country")
+	if err != nil {
+		return nil, err
+	}
+
+	method, err := getQualStringValue(d, "method")
+	if err != nil {
+		return nil, err
+	}
+
+	template := &TrafficAnomalyRow{
+		SiteUrl:      d.EqualsQualString("site_url"),
+		PagePrefix:   pagePrefix,
+		Country:      country,
+		SearchType:   searchType,
+		DataState:    dataState,
+		LookbackDays: trafficAnomalyDefaultLookbackDays,
+		BaselineDays: trafficAnomalyDefaultBaselineDays,
+		Method:       "z_score",
+	}
+
+	if d.EqualsQuals["lookback_days"] != nil {
+		template.LookbackDays = d.EqualsQuals["lookback_days"].GetInt64Value()
+		if template.LookbackDays < 1 {
+			return nil, fmt.Errorf("invalid lookback_days %d, the lookback_days should be at least 1", template.LookbackDays)
+		}
+	}
+	if d.EqualsQuals["baseline_days"] != nil {
+		template.BaselineDays = d.EqualsQuals["baseline_days"].GetInt64Value()
+		if template.BaselineDays < 2 {
+			return nil, fmt.Errorf("invalid baseline_days %d, the baseline_days should be at least 2", template.BaselineDays)
+		}
+	}
+
+	if method != "" {
+		template.Method = method
+	}
+	switch template.Method {
+	case "z_score":
+		template.Threshold = trafficAnomalyDefaultZScoreThreshold
+	case "percent":
+		template.Threshold = trafficAnomalyDefaultPercentThreshold
+	default:
+		return nil, fmt.Errorf("invalid method %q, the method should be 'z_score' or 'percent'", template.Method)
+	}
+	if d.EqualsQuals["threshold"] != nil {
+		template.Threshold = d.EqualsQuals["threshold"].GetDoubleValue()
+	}
+
+	return template, nil
+}
+
+// setTrafficAnomalyDeviation sets the baseline of the row from the values of the baseline
+// days, and the deviation of the value of the row from it.
+func setTrafficAnomalyDeviation(row *TrafficAnomalyRow, baselineValues []float64) {
+	var sum float64
+	for _, value := range baselineValues {
+		sum += value
+	}
+	row.Baseline = sum / float64(len(baselineValues))
+
+	var squares float64
+	for _, value := range baselineValues {
+		squares += (value - row.Baseline) * (value - row.Baseline)
+	}
+	row.BaselineStddev = math.Sqrt(squares / float64(len(baselineValues)))
+
+	row.Deviation = row.Value - row.Baseline
+	row.DeviationPercent = percentChange(row.Value, row.Baseline)
+	if row.BaselineStddev > 0 {
+		zScore := row.Deviation / row.BaselineStddev
+		row.ZScore = &zScore
+	}
+
+	switch {
+	case row.Deviation > 0:
+		row.Direction = "up"
+	case row.Deviation < 0:
+		row.Direction = "down"
+	}
+
+	switch row.Method {
+	case "z_score":
+		// Any change from a flat baseline is infinitely many standard deviations away
+		if row.ZScore == nil {
+			row.IsAnomaly = row.Deviation != 0
+		} else {
+			row.IsAnomaly = math.Abs(*row.ZScore) >= row.Threshold
+		}
+	case "percent":
+		row.IsAnomaly = row.DeviationPercent != nil && math.Abs(*row.DeviationPercent) >= row.Threshold
+	}
+}
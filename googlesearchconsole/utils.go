@@ -6,9 +6,13 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"regexp"
+	"slices"
+	"strings"
 	"sync"
 
 	"github.com/mitchellh/go-homedir"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
 	"github.com/turbot/steampipe-plugin-sdk/v5/memoize"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
 	sitemapper "github.com/yterajima/go-sitemap"
@@ -17,7 +21,6 @@ import (
 )
 
 var (
-	statusPerUrl            = make(map[string]*searchconsole.UrlInspectionResult)
 	pagespeedAnalysisPerUrl = make(map[string]*pagespeedonline.PagespeedApiPagespeedResponseV5)
 	mutex                   sync.Mutex
 )
@@ -55,6 +58,55 @@ func pathOrContents(poc string) (string, error) {
 	return poc, nil
 }
 
+// likePatternLiteral returns the unescaped pattern if a SQL LIKE pattern contains no wildcards.
+func likePatternLiteral(pattern string) (string, bool) {
+	var sb strings.Builder
+	escaped := false
+	for _, r := range pattern {
+		if !escaped && (r == '%' || r == '_') {
+			return "", false
+		}
+		if !escaped && r == '\\' {
+			escaped = true
+			continue
+		}
+		escaped = false
+		sb.WriteRune(r)
+	}
+	return sb.String(), true
+}
+
+// likePatternContains returns the inner literal of a SQL LIKE pattern of the form '%literal%'.
+func likePatternContains(pattern string) (string, bool) {
+	if len(pattern) < 3 || !strings.HasPrefix(pattern, "%") || !strings.HasSuffix(pattern, "%") || strings.HasSuffix(pattern, "\\%") {
+		return "", false
+	}
+	return likePatternLiteral(pattern[1 : len(pattern)-1])
+}
+
+// likePatternToRegex converts a SQL LIKE pattern to an anchored RE2 regular expression.
+func likePatternToRegex(pattern string) string {
+	var sb strings.Builder
+	sb.WriteString("^")
+	escaped := false
+	for _, r := range pattern {
+		switch {
+		case !escaped && r == '\\':
+			escaped = true
+			continue
+		case !escaped && r == '%':
+			sb.WriteString(".*")
+		case !escaped && r == '_':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+		escaped = false
+	}
+	sb.WriteString("$")
+	return sb.String()
+}
+
 // createBatches divides the slice into smaller slices of the given size.
 func createBatches(urls []sitemapper.URL, size int) [][]sitemapper.URL {
 	var batches [][]sitemapper.URL
@@ -65,32 +117,196 @@ func createBatches(urls []sitemapper.URL, size int) [][]sitemapper.URL {
 	return batches
 }
 
-// processPageIndexingStatusBatch processes a batch of URLs concurrently.
-func processPageIndexingStatusBatch(ctx context.Context, d *plugin.QueryData, siteUrl string, urls []sitemapper.URL, batchIndex int, wg *sync.WaitGroup) {
-	var batchWG sync.WaitGroup
-	batchWG.Add(len(urls))
+// getBrandTerms compiles the brand_terms regular expressions of the connection config.
+func getBrandTerms(d *plugin.QueryData) ([]*regexp.Regexp, error) {
+	var brandTerms []*regexp.Regexp
+	for _, term := range GetConfig(d.Connection).BrandTerms {
+		re, err := regexp.Compile(term)
+		if err != nil {
+			return nil, fmt.Errorf("invalid brand_terms regular expression %q: %v", term, err)
+		}
+		brandTerms = append(brandTerms, re)
+	}
+	return brandTerms, nil
+}
+
+// isBrandedQuery returns whether the query matches any of the brand terms, or nil if no
+// brand terms are configured.
+func isBrandedQuery(brandTerms []*regexp.Regexp, query string) *bool {
+	if len(brandTerms) == 0 {
+		return nil
+	}
+	isBranded := slices.ContainsFunc(brandTerms, func(re *regexp.Regexp) bool {
+		return re.MatchString(query)
+	})
+	return &isBranded
+}
 
+type pageGroup struct {
+	Name  string
+	Regex *regexp.Regexp
+}
+
+// getPageGroups parses the page_groups of the connection config. Each page group is defined
+// as "name=regex", where the regular expression is matched against the full URL of a page.
+func getPageGroups(d *plugin.QueryData) ([]pageGroup, error) {
+	var pageGroups []pageGroup
+	for _, definition := range GetConfig(d.Connection).PageGroups {
+		name, expr, ok := strings.Cut(definition, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid page_groups entry %q, the entry should be of the form \"name=regex\"", definition)
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid page_groups regular expression for %q: %v", name, err)
+		}
+		pageGroups = append(pageGroups, pageGroup{Name: name, Regex: re})
+	}
+	return pageGroups, nil
+}
+
+// getPageGroupName returns the name of the first page group matching the URL, or an empty
+// string if there is none.
+func getPageGroupName(pageGroups []pageGroup, url string) string {
+	for _, group := range pageGroups {
+		if group.Regex.MatchString(url) {
+			return group.Name
+		}
+	}
+	return ""
+}
+
+// pageIndexingStatusMaxConcurrency is the maximum number of URL inspection requests in flight
+const pageIndexingStatusMaxConcurrency = 50
+
+// pageIndexingStatus is the inspection result of a URL, or the error returned when inspecting it.
+type pageIndexingStatus struct {
+	Result *searchconsole.UrlInspectionResult
+	Err    error
+}
+
+// getPageIndexingStatuses inspects the URLs, with up to pageIndexingStatusMaxConcurrency requests
+// in flight, and returns the inspection results by URL. A URL that could not be inspected has its
+// error set instead, so that the other URLs are still returned.
+func getPageIndexingStatuses(ctx context.Context, d *plugin.QueryData, siteUrl string, languageCode string, urls []sitemapper.URL) map[string]pageIndexingStatus {
+	statuses := make(map[string]pageIndexingStatus, len(urls))
+	var statusesMutex sync.Mutex
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, pageIndexingStatusMaxConcurrency)
 	for _, url := range urls {
+		sem <- struct{}{}
+		wg.Add(1)
 		go func(url sitemapper.URL) {
-			defer batchWG.Done()
-			status, err := getPageIndexingStatusService(ctx, d, url.Loc, siteUrl)
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			result, err := getPageIndexingStatusService(ctx, d, url.Loc, siteUrl, languageCode)
 			if err != nil {
-				plugin.Logger(ctx).Error("Error fetching status for %s: %v\n", url.Loc, err)
-				return
+				plugin.Logger(ctx).Warn("getPageIndexingStatuses", "url", url.Loc, "api_error", err)
 			}
 
-			result := status
-
-			mutex.Lock()
-			statusPerUrl[url.Loc] = result
-			mutex.Unlock()
+			statusesMutex.Lock()
+			statuses[url.Loc] = pageIndexingStatus{Result: result, Err: err}
+			statusesMutex.Unlock()
 		}(url)
 	}
+	wg.Wait()
 
-	batchWG.Wait()
-	wg.Done()
+	return statuses
+}
 
-	plugin.Logger(ctx).Info("Batch %d complete\n", batchIndex+1)
+// getQualStringValues returns the distinct values of an equality qual on a string column,
+// which is a list for "in" and joins.
+func getQualStringValues(d *plugin.QueryData, column string) []string {
+	value := d.EqualsQuals[column]
+	if value == nil {
+		return nil
+	}
+	values := []*proto.QualValue{value}
+	if list := value.GetListValue(); list != nil {
+		values = list.Values
+	}
+	var result []string
+	for _, v := range values {
+		if s := v.GetStringValue(); s != "" && !slices.Contains(result, s) {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// getQualStringValue returns the value of an equality qual on a string column. The SDK only
+// splits a single list qual into separate calls, so a list can still reach the table when
+// several key columns have one, and is rejected.
+func getQualStringValue(d *plugin.QueryData, column string) (string, error) {
+	values := getQualStringValues(d, column)
+	if len(values) > 1 {
+		return "", fmt.Errorf("only one %s can be specified, got %d", column, len(values))
+	}
+	if len(values) == 0 {
+		return "", nil
+	}
+	return values[0], nil
+}
+
+// getPageInspectionURLs returns the URLs to inspect: the pages of the sitemap of the sitemap_url
+// qual if set, restricted to the given pages if any, otherwise the given pages themselves.
+func getPageInspectionURLs(ctx context.Context, d *plugin.QueryData, sitemapUrl string, pageUrls []string) ([]sitemapper.URL, error) {
+	if sitemapUrl == "" {
+		urls := make([]sitemapper.URL, len(pageUrls))
+		for i, pageUrl := range pageUrls {
+			urls[i] = sitemapper.URL{Loc: pageUrl}
+		}
+		return urls, nil
+	}
+
+	sitemap, err := getSitemapURLsMemoized(ctx, d, nil)
+	if err != nil {
+		return nil, err
+	}
+	sitemapURLs := sitemap.([]sitemapper.URL)
+	if len(pageUrls) == 0 {
+		return sitemapURLs, nil
+	}
+
+	// Keep the sitemap entries of the pages, so their metadata is still reported
+	var urls []sitemapper.URL
+	for _, url := range sitemapURLs {
+		if slices.Contains(pageUrls, url.Loc) {
+			urls = append(urls, url)
+		}
+	}
+	return urls, nil
+}
+
+// The sitemap is fetched once and shared by the calls for the same sitemap_url, e.g. the get
+// calls of each page of a loc list.
+var getSitemapURLsMemoized = plugin.HydrateFunc(getSitemapURLsUncached).Memoize(memoize.WithCacheKeyFunction(getSitemapURLsCacheKey))
+
+// Build a cache key for the call to getSitemapURLs.
+func getSitemapURLsCacheKey(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	sitemapUrl, err := getQualStringValue(d, "sitemap_url")
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("getSitemapURLs-%s", sitemapUrl)
+	return key, nil
+}
+
+func getSitemapURLsUncached(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	sitemapUrl, err := getQualStringValue(d, "sitemap_url")
+	if err != nil {
+		return nil, err
+	}
+
+	sitemap, err := sitemapper.Get(sitemapUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	return sitemap.URL, nil
 }
 
 // processPagespeedAnalysisBatch processes a batch of URLs concurrently.